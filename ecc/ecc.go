@@ -0,0 +1,78 @@
+// Package ecc secp256r1 public key decoding and signature verification shared by tx and vm
+package ecc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ECC errors
+var (
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	ErrNotOnCurve       = errors.New("public key not on curve")
+)
+
+// DecodePublicKey decode compressed or uncompressed secp256r1 public key
+func DecodePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+	params := curve.Params()
+
+	if len(data) == 65 && data[0] == 0x04 {
+		x := new(big.Int).SetBytes(data[1:33])
+		y := new(big.Int).SetBytes(data[33:])
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrNotOnCurve
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	if len(data) != 33 || (data[0] != 0x02 && data[0] != 0x03) {
+		return nil, fmt.Errorf("%w %x", ErrInvalidPublicKey, data)
+	}
+
+	x := new(big.Int).SetBytes(data[1:])
+
+	// y^2 = x^3 - 3x + b
+	y2 := new(big.Int).Exp(x, big.NewInt(3), params.P)
+	y2.Sub(y2, new(big.Int).Mul(x, big.NewInt(3)))
+	y2.Add(y2, params.B)
+	y2.Mod(y2, params.P)
+
+	y := new(big.Int).ModSqrt(y2, params.P)
+
+	if y == nil {
+		return nil, ErrNotOnCurve
+	}
+
+	if y.Bit(0) != uint(data[0]&0x01) {
+		y.Sub(params.P, y)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// VerifySignature verify 64 bytes r|s signature of sha256(data) with compressed or uncompressed public key
+func VerifySignature(publicKey []byte, data []byte, signature []byte) bool {
+	if len(signature) != 64 {
+		return false
+	}
+
+	pub, err := DecodePublicKey(publicKey)
+
+	if err != nil {
+		return false
+	}
+
+	digest := sha256.Sum256(data)
+
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+
+	return ecdsa.Verify(pub, digest[:], r, s)
+}
@@ -0,0 +1,475 @@
+package interop
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/inwecrypto/neogo/script"
+	"github.com/inwecrypto/neogo/tx"
+	"github.com/inwecrypto/neogo/vm"
+)
+
+// Block block of the chain snapshot, also used as header
+type Block struct {
+	Index        uint32
+	Hash         []byte // little endian UInt256
+	PrevHash     []byte // little endian UInt256
+	Timestamp    uint32
+	Transactions []*tx.Transaction
+}
+
+// Contract deployed contract
+type Contract struct {
+	Script     []byte
+	HasStorage bool
+}
+
+type chainTx struct {
+	tx     *tx.Transaction
+	height uint32
+}
+
+// MemoryChain in memory blockchain snapshot of blocks, transactions and contracts
+type MemoryChain struct {
+	blocks       []*Block
+	hashes       map[string]*Block
+	transactions map[string]*chainTx
+	contracts    map[string]*Contract
+}
+
+// NewMemoryChain create empty chain
+func NewMemoryChain() *MemoryChain {
+	return &MemoryChain{
+		hashes:       make(map[string]*Block),
+		transactions: make(map[string]*chainTx),
+		contracts:    make(map[string]*Contract),
+	}
+}
+
+// AddBlock append block of transactions, the block hash is derived from index, previous hash and timestamp
+func (chain *MemoryChain) AddBlock(timestamp uint32, txs ...*tx.Transaction) (*Block, error) {
+	block := &Block{
+		Index:        uint32(len(chain.blocks)),
+		PrevHash:     make([]byte, 32),
+		Timestamp:    timestamp,
+		Transactions: txs,
+	}
+
+	if block.Index > 0 {
+		block.PrevHash = chain.blocks[block.Index-1].Hash
+	}
+
+	data := make([]byte, 8)
+
+	binary.LittleEndian.PutUint32(data, block.Index)
+	binary.LittleEndian.PutUint32(data[4:], timestamp)
+
+	hash := sha256.Sum256(append(append([]byte{}, block.PrevHash...), data...))
+
+	block.Hash = hash[:]
+
+	for _, transaction := range txs {
+		txHash, err := transaction.Hash()
+
+		if err != nil {
+			return nil, err
+		}
+
+		chain.transactions[string(txHash)] = &chainTx{
+			tx:     transaction,
+			height: block.Index,
+		}
+	}
+
+	chain.blocks = append(chain.blocks, block)
+	chain.hashes[string(block.Hash)] = block
+
+	return block, nil
+}
+
+// Height get index of the latest block
+func (chain *MemoryChain) Height() uint32 {
+	if len(chain.blocks) == 0 {
+		return 0
+	}
+
+	return uint32(len(chain.blocks) - 1)
+}
+
+// Block get block by index
+func (chain *MemoryChain) Block(index uint32) (*Block, bool) {
+	if int64(index) >= int64(len(chain.blocks)) {
+		return nil, false
+	}
+
+	return chain.blocks[index], true
+}
+
+// BlockByHash get block by little endian hash
+func (chain *MemoryChain) BlockByHash(hash []byte) (*Block, bool) {
+	block, ok := chain.hashes[string(hash)]
+
+	return block, ok
+}
+
+// Transaction get transaction and the height of its block by little endian hash
+func (chain *MemoryChain) Transaction(hash []byte) (*tx.Transaction, uint32, bool) {
+	target, ok := chain.transactions[string(hash)]
+
+	if !ok {
+		return nil, 0, false
+	}
+
+	return target.tx, target.height, true
+}
+
+// AddContract deploy contract script, return the little endian script hash
+func (chain *MemoryChain) AddContract(code []byte, hasStorage bool) []byte {
+	scriptHash := script.Hash(code)
+
+	chain.contracts[string(scriptHash)] = &Contract{
+		Script:     code,
+		HasStorage: hasStorage,
+	}
+
+	return scriptHash
+}
+
+// Contract get contract by little endian script hash
+func (chain *MemoryChain) Contract(scriptHash []byte) (*Contract, bool) {
+	contract, ok := chain.contracts[string(scriptHash)]
+
+	return contract, ok
+}
+
+// GetScript implement vm.ScriptTable
+func (chain *MemoryChain) GetScript(scriptHash []byte) ([]byte, error) {
+	contract, ok := chain.Contract(scriptHash)
+
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownContract, reverseHash(scriptHash))
+	}
+
+	return contract.Script, nil
+}
+
+func registerChain(service *Service) {
+	service.Register("Neo.Blockchain.GetHeight", 1, chainGetHeight)
+	service.Register("Neo.Blockchain.GetHeader", 100, chainGetBlock)
+	service.Register("Neo.Blockchain.GetBlock", 200, chainGetBlock)
+	service.Register("Neo.Blockchain.GetTransaction", 100, chainGetTransaction)
+	service.Register("Neo.Blockchain.GetTransactionHeight", 100, chainGetTransactionHeight)
+	service.Register("Neo.Blockchain.GetContract", 100, chainGetContract)
+
+	service.Register("Neo.Header.GetIndex", 1, headerGetIndex)
+	service.Register("Neo.Header.GetHash", 1, headerGetHash)
+	service.Register("Neo.Header.GetPrevHash", 1, headerGetPrevHash)
+	service.Register("Neo.Header.GetTimestamp", 1, headerGetTimestamp)
+
+	service.Register("Neo.Block.GetTransactionCount", 1, blockGetTransactionCount)
+	service.Register("Neo.Block.GetTransactions", 1, blockGetTransactions)
+	service.Register("Neo.Block.GetTransaction", 1, blockGetTransaction)
+
+	service.Register("Neo.Transaction.GetHash", 1, transactionGetHash)
+	service.Register("Neo.Transaction.GetType", 1, transactionGetType)
+
+	service.Register("Neo.Contract.GetScript", 1, contractGetScript)
+	service.Register("Neo.Contract.IsPayable", 1, contractIsPayable)
+}
+
+func chainGetHeight(service *Service, engine *vm.Engine) error {
+	pushInt(engine, int64(service.Chain.Height()))
+
+	return nil
+}
+
+// chainGetBlock the argument is a block index or a 32 bytes hash, missing block is pushed as empty interop
+func chainGetBlock(service *Service, engine *vm.Engine) error {
+	data, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	var block *Block
+
+	switch {
+	case len(data) <= 5:
+		index := vm.BytesToInt(data)
+
+		if index.Sign() >= 0 && index.IsUint64() && index.Uint64() <= uint64(^uint32(0)) {
+			block, _ = service.Chain.Block(uint32(index.Uint64()))
+		}
+	case len(data) == 32:
+		block, _ = service.Chain.BlockByHash(data)
+	default:
+		return fmt.Errorf("%w: block index or hash length %d", vm.ErrInvalidArgument, len(data))
+	}
+
+	if block == nil {
+		pushInterop(engine, nil)
+
+		return nil
+	}
+
+	pushInterop(engine, block)
+
+	return nil
+}
+
+func chainGetTransaction(service *Service, engine *vm.Engine) error {
+	hash, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	if transaction, _, ok := service.Chain.Transaction(hash); ok {
+		pushInterop(engine, transaction)
+	} else {
+		pushInterop(engine, nil)
+	}
+
+	return nil
+}
+
+func chainGetTransactionHeight(service *Service, engine *vm.Engine) error {
+	hash, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	height := int64(-1)
+
+	if _, index, ok := service.Chain.Transaction(hash); ok {
+		height = int64(index)
+	}
+
+	pushInt(engine, height)
+
+	return nil
+}
+
+func chainGetContract(service *Service, engine *vm.Engine) error {
+	scriptHash, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	if contract, ok := service.Chain.Contract(scriptHash); ok {
+		pushInterop(engine, contract)
+	} else {
+		pushInterop(engine, nil)
+	}
+
+	return nil
+}
+
+func popBlock(engine *vm.Engine) (*Block, error) {
+	value, err := popInterop(engine)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := value.(*Block)
+
+	if !ok {
+		return nil, ErrInvalidInterop
+	}
+
+	return block, nil
+}
+
+func headerGetIndex(service *Service, engine *vm.Engine) error {
+	block, err := popBlock(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushInt(engine, int64(block.Index))
+
+	return nil
+}
+
+func headerGetHash(service *Service, engine *vm.Engine) error {
+	block, err := popBlock(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushBytes(engine, block.Hash)
+
+	return nil
+}
+
+func headerGetPrevHash(service *Service, engine *vm.Engine) error {
+	block, err := popBlock(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushBytes(engine, block.PrevHash)
+
+	return nil
+}
+
+func headerGetTimestamp(service *Service, engine *vm.Engine) error {
+	block, err := popBlock(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushInt(engine, int64(block.Timestamp))
+
+	return nil
+}
+
+func blockGetTransactionCount(service *Service, engine *vm.Engine) error {
+	block, err := popBlock(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushInt(engine, int64(len(block.Transactions)))
+
+	return nil
+}
+
+func blockGetTransactions(service *Service, engine *vm.Engine) error {
+	block, err := popBlock(engine)
+
+	if err != nil {
+		return err
+	}
+
+	if len(block.Transactions) > vm.MaxArraySize {
+		return vm.ErrLimit
+	}
+
+	array := vm.NewArray()
+
+	for _, transaction := range block.Transactions {
+		array.Items = append(array.Items, vm.NewInteropInterface(transaction))
+	}
+
+	engine.EvaluationStack.Push(array)
+
+	return nil
+}
+
+func blockGetTransaction(service *Service, engine *vm.Engine) error {
+	block, err := popBlock(engine)
+
+	if err != nil {
+		return err
+	}
+
+	index, err := popInt(engine)
+
+	if err != nil {
+		return err
+	}
+
+	if index.Sign() < 0 || index.Cmp(big.NewInt(int64(len(block.Transactions)))) >= 0 {
+		return fmt.Errorf("%w: transaction index %s", vm.ErrInvalidArgument, index)
+	}
+
+	pushInterop(engine, block.Transactions[index.Int64()])
+
+	return nil
+}
+
+func popTransaction(engine *vm.Engine) (*tx.Transaction, error) {
+	value, err := popInterop(engine)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, ok := value.(*tx.Transaction)
+
+	if !ok {
+		return nil, ErrInvalidInterop
+	}
+
+	return transaction, nil
+}
+
+func transactionGetHash(service *Service, engine *vm.Engine) error {
+	transaction, err := popTransaction(engine)
+
+	if err != nil {
+		return err
+	}
+
+	hash, err := transaction.Hash()
+
+	if err != nil {
+		return err
+	}
+
+	pushBytes(engine, hash)
+
+	return nil
+}
+
+func transactionGetType(service *Service, engine *vm.Engine) error {
+	transaction, err := popTransaction(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushInt(engine, int64(transaction.Type))
+
+	return nil
+}
+
+func popContract(engine *vm.Engine) (*Contract, error) {
+	value, err := popInterop(engine)
+
+	if err != nil {
+		return nil, err
+	}
+
+	contract, ok := value.(*Contract)
+
+	if !ok {
+		return nil, ErrInvalidInterop
+	}
+
+	return contract, nil
+}
+
+func contractGetScript(service *Service, engine *vm.Engine) error {
+	contract, err := popContract(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushBytes(engine, contract.Script)
+
+	return nil
+}
+
+func contractIsPayable(service *Service, engine *vm.Engine) error {
+	if _, err := popContract(engine); err != nil {
+		return err
+	}
+
+	// payable contracts are not modeled, transfers to contracts are always accepted
+	engine.EvaluationStack.Push(vm.Boolean(true))
+
+	return nil
+}
@@ -0,0 +1,315 @@
+package interop
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/inwecrypto/neogo/rpc"
+	"github.com/inwecrypto/neogo/tx"
+	"github.com/inwecrypto/neogo/vm"
+)
+
+// Interop errors
+var (
+	ErrUnknownSysCall    = errors.New("unknown syscall")
+	ErrInvalidInterop    = errors.New("invalid interop interface")
+	ErrTrigger           = errors.New("syscall not allowed by trigger")
+	ErrReadOnly          = errors.New("storage context is read only")
+	ErrStorageKeySize    = errors.New("storage key too long")
+	ErrNoStorage         = errors.New("contract has no storage")
+	ErrRecursiveItem     = errors.New("recursive stack item")
+	ErrInvalidPublicKey  = errors.New("invalid public key or script hash")
+	ErrUnknownContract   = errors.New("unknown contract")
+	ErrNoScriptContainer = errors.New("script container not set")
+)
+
+// Triggers of neo 2.x
+const (
+	TriggerVerification byte = 0x00
+	TriggerApplication  byte = 0x10
+)
+
+// Handler interop service handler, arguments are popped from and results pushed to the evaluation stack
+type Handler func(service *Service, engine *vm.Engine) error
+
+// PriceFunc get gas units of an api call from the arguments on the evaluation stack before the handler runs
+type PriceFunc func(engine *vm.Engine) (int64, error)
+
+type interop struct {
+	price   PriceFunc
+	handler Handler
+}
+
+// Log Neo.Runtime.Log message
+type Log struct {
+	Contract string // 0x prefixed big endian script hash
+	Message  string
+}
+
+// Service interop service layer of SYSCALL apis, the state is kept in memory so that
+// contracts can be executed offline and the results inspected by tests
+type Service struct {
+	Storage       *MemoryStorage
+	Chain         *MemoryChain
+	Trigger       byte
+	Time          uint32          // Neo.Runtime.GetTime, the latest block timestamp when 0
+	Container     *tx.Transaction // script container
+	Witnesses     [][]byte        // script hashes accepted by Neo.Runtime.CheckWitness
+	Notifications []*rpc.Notification
+	Logs          []*Log
+	resolver      tx.OutputResolver
+	services      map[string]*interop
+}
+
+// ServiceOption NewService option
+type ServiceOption func(service *Service)
+
+// WithTrigger set trigger, default is TriggerApplication
+func WithTrigger(trigger byte) ServiceOption {
+	return func(service *Service) {
+		service.Trigger = trigger
+	}
+}
+
+// WithTime set Neo.Runtime.GetTime result
+func WithTime(time uint32) ServiceOption {
+	return func(service *Service) {
+		service.Time = time
+	}
+}
+
+// WithStorage share storage, e.g. between several executions
+func WithStorage(storage *MemoryStorage) ServiceOption {
+	return func(service *Service) {
+		service.Storage = storage
+	}
+}
+
+// WithChain share blockchain snapshot
+func WithChain(chain *MemoryChain) ServiceOption {
+	return func(service *Service) {
+		service.Chain = chain
+	}
+}
+
+// WithContainer set script container, the script hashes verifying the transaction are accepted as witnesses,
+// resolver finds the owners of inputs, when it is nil the owners come from Vin.Address and unresolved owners
+// of e.g. parsed transactions must be added by WithWitnesses
+func WithContainer(container *tx.Transaction, resolver tx.OutputResolver) ServiceOption {
+	return func(service *Service) {
+		service.Container = container
+		service.resolver = resolver
+	}
+}
+
+// WithWitnesses accept script hashes by Neo.Runtime.CheckWitness
+func WithWitnesses(scriptHashes ...[]byte) ServiceOption {
+	return func(service *Service) {
+		service.Witnesses = append(service.Witnesses, scriptHashes...)
+	}
+}
+
+// NewService create interop service with the default neo 2.x apis registered
+func NewService(options ...ServiceOption) (*Service, error) {
+	service := &Service{
+		Trigger:  TriggerApplication,
+		services: make(map[string]*interop),
+	}
+
+	for _, option := range options {
+		option(service)
+	}
+
+	if service.Storage == nil {
+		service.Storage = NewMemoryStorage()
+	}
+
+	if service.Chain == nil {
+		service.Chain = NewMemoryChain()
+	}
+
+	if service.Container != nil {
+		// fill SignData of unsigned containers
+		if _, err := service.Container.Hash(); err != nil {
+			return nil, err
+		}
+
+		hashes, err := service.Container.ScriptHashesForVerifying(service.resolver)
+
+		if err != nil {
+			if service.resolver != nil || !errors.Is(err, tx.ErrUnresolvedInput) {
+				return nil, err
+			}
+
+			// input owners are unknown without resolver, keep the script attributes
+			hashes = nil
+
+			for _, attr := range service.Container.Attributes {
+				if scriptHash, ok := attr.ScriptHash(); ok {
+					hashes = append(hashes, scriptHash)
+				}
+			}
+		}
+
+		service.Witnesses = append(service.Witnesses, hashes...)
+	}
+
+	registerStorage(service)
+	registerRuntime(service)
+	registerEngine(service)
+	registerChain(service)
+
+	return service, nil
+}
+
+// Register register or replace api, price is gas units including the unit of the SYSCALL instruction
+func (service *Service) Register(api string, price int64, handler Handler) {
+	service.RegisterPriced(api, func(engine *vm.Engine) (int64, error) {
+		return price, nil
+	}, handler)
+}
+
+// RegisterPriced register or replace api which price depends on its arguments, e.g. the size of stored data
+func (service *Service) RegisterPriced(api string, price PriceFunc, handler Handler) {
+	service.services[api] = &interop{
+		price:   price,
+		handler: handler,
+	}
+}
+
+// APIs get registered api names in order
+func (service *Service) APIs() []string {
+	var apis []string
+
+	for api := range service.services {
+		apis = append(apis, api)
+	}
+
+	sort.Strings(apis)
+
+	return apis
+}
+
+// SysCall implement vm.SysCallHandler
+func (service *Service) SysCall(engine *vm.Engine, api string) error {
+	interop, ok := service.services[api]
+
+	if !ok {
+		return fmt.Errorf("%w %s", ErrUnknownSysCall, api)
+	}
+
+	price, err := interop.price(engine)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", api, err)
+	}
+
+	// the engine already charged one unit for SYSCALL
+	if price > 1 {
+		if err := engine.AddGas(price - 1); err != nil {
+			return err
+		}
+	}
+
+	if err := interop.handler(service, engine); err != nil {
+		return fmt.Errorf("%s: %w", api, err)
+	}
+
+	return nil
+}
+
+// EngineOptions get engine options using the service as syscall handler and the chain as script table
+func (service *Service) EngineOptions() []vm.EngineOption {
+	options := []vm.EngineOption{
+		vm.WithSysCall(service.SysCall),
+		vm.WithScriptTable(service.Chain),
+	}
+
+	if service.Container != nil {
+		options = append(options, vm.WithMessage(service.Container.SignData))
+	}
+
+	return options
+}
+
+// Run execute script with the service
+func (service *Service) Run(code []byte, options ...vm.EngineOption) *vm.Engine {
+	return vm.Run(code, append(service.EngineOptions(), options...)...)
+}
+
+func pop(engine *vm.Engine) (vm.StackItem, error) {
+	return engine.EvaluationStack.Pop()
+}
+
+func popBytes(engine *vm.Engine) ([]byte, error) {
+	item, err := pop(engine)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := item.Bytes()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", vm.ErrInvalidType, err)
+	}
+
+	return data, nil
+}
+
+func popInt(engine *vm.Engine) (*big.Int, error) {
+	item, err := pop(engine)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := item.BigInt()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", vm.ErrInvalidType, err)
+	}
+
+	return value, nil
+}
+
+func popInterop(engine *vm.Engine) (interface{}, error) {
+	item, err := pop(engine)
+
+	if err != nil {
+		return nil, err
+	}
+
+	interop, ok := item.(*vm.InteropInterface)
+
+	if !ok || interop.Value == nil {
+		return nil, ErrInvalidInterop
+	}
+
+	return interop.Value, nil
+}
+
+func pushInt(engine *vm.Engine, value int64) {
+	engine.EvaluationStack.Push(vm.NewInteger(big.NewInt(value)))
+}
+
+func pushBytes(engine *vm.Engine, data []byte) {
+	engine.EvaluationStack.Push(vm.ByteArray(data))
+}
+
+func pushInterop(engine *vm.Engine, value interface{}) {
+	engine.EvaluationStack.Push(vm.NewInteropInterface(value))
+}
+
+// reverseHash convert little endian UInt160 or UInt256 to the big endian 0x prefixed string
+func reverseHash(hash []byte) string {
+	reversed := make([]byte, len(hash))
+
+	for i, b := range hash {
+		reversed[len(hash)-1-i] = b
+	}
+
+	return fmt.Sprintf("0x%x", reversed)
+}
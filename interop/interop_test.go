@@ -0,0 +1,322 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/inwecrypto/neogo/keystore"
+	"github.com/inwecrypto/neogo/rpc"
+	"github.com/inwecrypto/neogo/script"
+	"github.com/inwecrypto/neogo/tx"
+	"github.com/inwecrypto/neogo/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assemble(t *testing.T, source string) []byte {
+	asm, err := script.Assemble(source)
+	require.NoError(t, err)
+
+	code, err := asm.Bytes()
+	require.NoError(t, err)
+
+	return code
+}
+
+// mint(owner, amount) adds amount to the balance of owner and notifies ["mint", owner, amount]
+const mintContract = `
+	DUP
+	SYSCALL Neo.Runtime.CheckWitness
+	THROWIFNOT
+	DUP
+	SYSCALL Neo.Storage.GetContext
+	SYSCALL Neo.Storage.Get
+	PUSH 2
+	PICK
+	ADD
+	OVER
+	SYSCALL Neo.Storage.GetContext
+	SYSCALL Neo.Storage.Put
+	PUSH "mint"
+	PUSH 3
+	PACK
+	SYSCALL Neo.Runtime.Notify
+	PUSH true
+	RET
+`
+
+func TestStorageAndNotify(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	publicKey := tx.NewKeySigner(key.PrivateKey).PublicKey()
+	owner := script.Hash(tx.CreateSignatureRedeemScript(publicKey))
+
+	chain := NewMemoryChain()
+
+	contractHash := chain.AddContract(assemble(t, mintContract), true)
+
+	entry := assemble(t, fmt.Sprintf(`
+		PUSH 100
+		PUSH 0x%x
+		APPCALL %s
+	`, owner, reverseHash(contractHash)))
+
+	storage := NewMemoryStorage()
+	storage.Put(contractHash, owner, vm.IntToBytes(big.NewInt(50)))
+
+	service, err := NewService(WithChain(chain), WithStorage(storage), WithWitnesses(owner))
+	require.NoError(t, err)
+
+	engine := service.Run(entry)
+	require.Equal(t, vm.Halt, engine.State, "%v", engine.Err)
+
+	balance, ok := storage.Get(contractHash, owner)
+	require.True(t, ok)
+	assert.Equal(t, "9600", hex.EncodeToString(balance))
+	assert.Equal(t, [][]byte{owner}, storage.Keys(contractHash))
+
+	require.Len(t, service.Notifications, 1)
+
+	data, err := json.Marshal(service.Notifications[0])
+	require.NoError(t, err)
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"contract": "%s",
+		"state": {
+			"type": "Array",
+			"value": [
+				{"type": "ByteArray", "value": "6d696e74"},
+				{"type": "ByteArray", "value": "%x"},
+				{"type": "ByteArray", "value": "64"}
+			]
+		}
+	}`, reverseHash(contractHash), owner), string(data))
+
+	// storage put costs 1 GAS per KB
+	assert.True(t, engine.GasConsumed > 1000*vm.GasRatio)
+
+	// CheckWitness accepts the public key too
+	engine = service.Run(assemble(t, fmt.Sprintf("PUSH 0x%x\nSYSCALL Neo.Runtime.CheckWitness", publicKey)))
+	require.Equal(t, vm.Halt, engine.State, "%v", engine.Err)
+	assert.True(t, engine.EvaluationStack.Items()[0].Bool())
+
+	// no witness
+	service, err = NewService(WithChain(chain), WithStorage(storage))
+	require.NoError(t, err)
+
+	engine = service.Run(entry)
+	assert.Equal(t, vm.Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, vm.ErrThrow), "%v", engine.Err)
+
+	// storage can't be written by verification
+	service, err = NewService(WithChain(chain), WithStorage(storage), WithWitnesses(owner), WithTrigger(TriggerVerification))
+	require.NoError(t, err)
+
+	engine = service.Run(entry)
+	assert.Equal(t, vm.Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrTrigger), "%v", engine.Err)
+
+	balance, _ = storage.Get(contractHash, owner)
+	assert.Equal(t, "9600", hex.EncodeToString(balance))
+}
+
+func TestBlockchain(t *testing.T) {
+	chain := NewMemoryChain()
+
+	_, err := chain.AddBlock(1000)
+	require.NoError(t, err)
+
+	remark, err := tx.NewRemarkAttribute(0, []byte("interop"))
+	require.NoError(t, err)
+
+	transaction := tx.NewContractTx().Tx()
+	transaction.Attributes = append(transaction.Attributes, remark)
+
+	block, err := chain.AddBlock(1015, transaction)
+	require.NoError(t, err)
+
+	hash, err := transaction.Hash()
+	require.NoError(t, err)
+
+	service, err := NewService(WithChain(chain))
+	require.NoError(t, err)
+
+	engine := service.Run(assemble(t, fmt.Sprintf(`
+		PUSH 0
+		PUSH 1
+		SYSCALL Neo.Blockchain.GetBlock
+		SYSCALL Neo.Block.GetTransaction
+		SYSCALL Neo.Transaction.GetHash
+		PUSH 0x%x
+		SYSCALL Neo.Blockchain.GetTransactionHeight
+		PUSH 0x%x
+		SYSCALL Neo.Blockchain.GetHeader
+		SYSCALL Neo.Header.GetPrevHash
+		SYSCALL Neo.Blockchain.GetHeight
+		SYSCALL Neo.Runtime.GetTime
+		PUSH 5
+		SYSCALL Neo.Blockchain.GetBlock
+	`, hash, block.Hash)))
+
+	require.Equal(t, vm.Halt, engine.State, "%v", engine.Err)
+
+	items := engine.EvaluationStack.Items()
+	require.Len(t, items, 6)
+
+	assert.False(t, items[0].Bool(), "missing block")
+	assert.Equal(t, "1015", fmt.Sprint(items[1]))
+	assert.Equal(t, "1", fmt.Sprint(items[2]))
+	assert.Equal(t, block.PrevHash, mustBytes(t, items[3]))
+	assert.Equal(t, "1", fmt.Sprint(items[4]))
+	assert.Equal(t, hash, mustBytes(t, items[5]))
+	assert.Equal(t, transaction.TxID, hex.EncodeToString(reverseBytes(hash)))
+}
+
+func TestSysCallErrors(t *testing.T) {
+	service, err := NewService()
+	require.NoError(t, err)
+
+	engine := service.Run(assemble(t, "SYSCALL Neo.Unknown.Api"))
+	assert.Equal(t, vm.Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrUnknownSysCall), "%v", engine.Err)
+
+	engine = service.Run(assemble(t, "PUSH 1\nSYSCALL Neo.Storage.Get"))
+	assert.Equal(t, vm.Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrInvalidInterop), "%v", engine.Err)
+
+	engine = service.Run(assemble(t, "PUSH 0\nSYSCALL Neo.Storage.GetReadOnlyContext\nSYSCALL Neo.Storage.Delete"))
+	assert.Equal(t, vm.Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrReadOnly), "%v", engine.Err)
+
+	// recursive array can't be notified
+	engine = service.Run(assemble(t, "PUSH 0\nNEWARRAY\nDUP\nDUP\nAPPEND\nSYSCALL Neo.Runtime.Notify"))
+	assert.Equal(t, vm.Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrRecursiveItem), "%v", engine.Err)
+
+	// custom api
+	service.Register("Test.Answer", 10, func(service *Service, engine *vm.Engine) error {
+		pushInt(engine, 42)
+
+		return nil
+	})
+
+	engine = service.Run(assemble(t, "SYSCALL Test.Answer"))
+	require.Equal(t, vm.Halt, engine.State, "%v", engine.Err)
+	assert.Equal(t, "42", fmt.Sprint(engine.EvaluationStack.Items()[0]))
+	assert.Equal(t, int64(11*vm.GasRatio), engine.GasConsumed)
+}
+
+func TestStoragePrice(t *testing.T) {
+	service, err := NewService()
+	require.NoError(t, err)
+
+	// pushes are free, GetContext, Put and the implicit RET
+	for size, expect := range map[int]int64{0: 1002, 1023: 1002, 1024: 2002, 3000: 3002} {
+		engine := service.Run(assemble(t, fmt.Sprintf(`
+			PUSH 0x%x
+			PUSH 0x01
+			SYSCALL Neo.Storage.GetContext
+			SYSCALL Neo.Storage.Put
+		`, make([]byte, size))))
+
+		require.Equal(t, vm.Halt, engine.State, "%v", engine.Err)
+		assert.Equal(t, expect*vm.GasRatio, engine.GasConsumed, "value size %d", size)
+	}
+
+	// the price is charged before the handler runs
+	service.RegisterPriced("Test.Priced", func(engine *vm.Engine) (int64, error) {
+		return 50, nil
+	}, func(service *Service, engine *vm.Engine) error {
+		return nil
+	})
+
+	engine := service.Run(assemble(t, "SYSCALL Test.Priced"), vm.WithGasLimit(20*vm.GasRatio))
+	assert.Equal(t, vm.Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, vm.ErrGasLimit), "%v", engine.Err)
+
+	engine = service.Run(assemble(t, "PUSH 1\nSYSCALL Neo.Storage.Put"))
+	assert.Equal(t, vm.Fault, engine.State)
+}
+
+func mustBytes(t *testing.T, item vm.StackItem) []byte {
+	data, err := item.Bytes()
+	require.NoError(t, err)
+
+	return data
+}
+
+func reverseBytes(data []byte) []byte {
+	reversed, _ := hex.DecodeString(reverseHash(data)[2:])
+
+	return reversed
+}
+
+func TestScriptContainer(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	signer := tx.NewKeySigner(key.PrivateKey)
+
+	owner, err := tx.DecodeAddress(key.Address)
+	require.NoError(t, err)
+
+	unspent := []*rpc.UTXO{
+		&rpc.UTXO{
+			TransactionID: fmt.Sprintf("0x%064x", 1),
+			Vout: rpc.Vout{
+				Address: key.Address,
+				Asset:   tx.NEOAssert,
+				Value:   rpc.Fixed8(10 * 100000000),
+			},
+		},
+	}
+
+	built, err := tx.From(key.Address).Pay(tx.NEOAssert, key.Address, tx.MakeFixed8(1)).Build(unspent)
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+
+	require.NoError(t, built.Write(&buff))
+
+	// parsed inputs have no owner address
+	parsed, err := tx.ParseTransaction(buff.Bytes())
+	require.NoError(t, err)
+
+	service, err := NewService(WithContainer(parsed, nil))
+	require.NoError(t, err)
+	assert.False(t, service.CheckWitness(owner))
+
+	service, err = NewService(WithContainer(parsed, nil), WithWitnesses(owner))
+	require.NoError(t, err)
+	assert.True(t, service.CheckWitness(owner))
+
+	service, err = NewService(WithContainer(parsed, tx.UTXOResolver(unspent)))
+	require.NoError(t, err)
+	assert.True(t, service.CheckWitness(owner))
+
+	_, err = NewService(WithContainer(parsed, tx.UTXOResolver(nil)))
+	assert.True(t, errors.Is(err, tx.ErrUnresolvedInput), "%v", err)
+
+	// the unsigned container is the CHECKSIG message
+	require.NotNil(t, parsed.SignData)
+
+	signature, err := signer.Sign(parsed.SignData)
+	require.NoError(t, err)
+
+	invocation, err := script.New("invocation").EmitPushBytes(signature).Bytes()
+	require.NoError(t, err)
+
+	engine := vm.NewEngine(service.EngineOptions()...)
+
+	require.NoError(t, engine.LoadScript(tx.CreateSignatureRedeemScript(signer.PublicKey())))
+	require.NoError(t, engine.LoadScript(invocation))
+
+	require.Equal(t, vm.Halt, engine.Execute(), "%v", engine.Err)
+	assert.True(t, engine.EvaluationStack.Items()[0].Bool())
+}
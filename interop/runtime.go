@@ -0,0 +1,240 @@
+package interop
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/inwecrypto/neogo/rpc"
+	"github.com/inwecrypto/neogo/script"
+	"github.com/inwecrypto/neogo/tx"
+	"github.com/inwecrypto/neogo/vm"
+)
+
+// MapEntry map entry of notification state, the value of a Map state is a list of entries
+type MapEntry struct {
+	Key   *rpc.State `json:"key"`
+	Value *rpc.State `json:"value"`
+}
+
+func registerRuntime(service *Service) {
+	service.Register("Neo.Runtime.GetTrigger", 1, runtimeGetTrigger)
+	service.Register("Neo.Runtime.GetTime", 1, runtimeGetTime)
+	service.Register("Neo.Runtime.CheckWitness", 200, runtimeCheckWitness)
+	service.Register("Neo.Runtime.Notify", 1, runtimeNotify)
+	service.Register("Neo.Runtime.Log", 1, runtimeLog)
+}
+
+func registerEngine(service *Service) {
+	service.Register("System.ExecutionEngine.GetScriptContainer", 1, engineGetScriptContainer)
+	service.Register("System.ExecutionEngine.GetExecutingScriptHash", 1, engineGetExecutingScriptHash)
+	service.Register("System.ExecutionEngine.GetCallingScriptHash", 1, engineGetCallingScriptHash)
+	service.Register("System.ExecutionEngine.GetEntryScriptHash", 1, engineGetEntryScriptHash)
+
+	// the same apis under the Neo namespace
+	service.Register("Neo.ExecutionEngine.GetScriptContainer", 1, engineGetScriptContainer)
+	service.Register("Neo.ExecutionEngine.GetExecutingScriptHash", 1, engineGetExecutingScriptHash)
+	service.Register("Neo.ExecutionEngine.GetCallingScriptHash", 1, engineGetCallingScriptHash)
+	service.Register("Neo.ExecutionEngine.GetEntryScriptHash", 1, engineGetEntryScriptHash)
+}
+
+func runtimeGetTrigger(service *Service, engine *vm.Engine) error {
+	pushInt(engine, int64(service.Trigger))
+
+	return nil
+}
+
+func runtimeGetTime(service *Service, engine *vm.Engine) error {
+	time := service.Time
+
+	if time == 0 {
+		if block, ok := service.Chain.Block(service.Chain.Height()); ok {
+			time = block.Timestamp
+		}
+	}
+
+	pushInt(engine, int64(time))
+
+	return nil
+}
+
+func runtimeCheckWitness(service *Service, engine *vm.Engine) error {
+	data, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	var scriptHash []byte
+
+	switch len(data) {
+	case 20:
+		scriptHash = data
+	case 33:
+		scriptHash = script.Hash(tx.CreateSignatureRedeemScript(data))
+	default:
+		return ErrInvalidPublicKey
+	}
+
+	engine.EvaluationStack.Push(vm.Boolean(service.CheckWitness(scriptHash)))
+
+	return nil
+}
+
+// CheckWitness check script hash is a witness of the execution
+func (service *Service) CheckWitness(scriptHash []byte) bool {
+	for _, witness := range service.Witnesses {
+		if bytes.Equal(witness, scriptHash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func runtimeNotify(service *Service, engine *vm.Engine) error {
+	item, err := pop(engine)
+
+	if err != nil {
+		return err
+	}
+
+	state, err := ToState(item)
+
+	if err != nil {
+		return err
+	}
+
+	service.Notifications = append(service.Notifications, &rpc.Notification{
+		Contract: reverseHash(engine.CurrentContext().ScriptHash()),
+		State:    *state,
+	})
+
+	return nil
+}
+
+func runtimeLog(service *Service, engine *vm.Engine) error {
+	message, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	service.Logs = append(service.Logs, &Log{
+		Contract: reverseHash(engine.CurrentContext().ScriptHash()),
+		Message:  string(message),
+	})
+
+	return nil
+}
+
+// ToState convert stack item to the rpc state of notifications and invocation results
+func ToState(item vm.StackItem) (*rpc.State, error) {
+	return toState(item, make(map[vm.StackItem]bool))
+}
+
+func toState(item vm.StackItem, parents map[vm.StackItem]bool) (*rpc.State, error) {
+	switch target := item.(type) {
+	case vm.ByteArray:
+		return &rpc.State{Type: "ByteArray", Value: fmt.Sprintf("%x", []byte(target))}, nil
+	case *vm.Integer:
+		return &rpc.State{Type: "Integer", Value: target.String()}, nil
+	case vm.Boolean:
+		return &rpc.State{Type: "Boolean", Value: bool(target)}, nil
+	case *vm.InteropInterface:
+		return &rpc.State{Type: "InteropInterface"}, nil
+	}
+
+	if parents[item] {
+		return nil, ErrRecursiveItem
+	}
+
+	parents[item] = true
+
+	defer delete(parents, item)
+
+	var items []vm.StackItem
+
+	switch target := item.(type) {
+	case *vm.Array:
+		items = target.Items
+	case *vm.Struct:
+		items = target.Items
+	case *vm.Map:
+		var entries []*MapEntry
+
+		for _, key := range target.Keys() {
+			value, _, err := target.Get(key)
+
+			if err != nil {
+				return nil, err
+			}
+
+			keyState, err := toState(key, parents)
+
+			if err != nil {
+				return nil, err
+			}
+
+			valueState, err := toState(value, parents)
+
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, &MapEntry{Key: keyState, Value: valueState})
+		}
+
+		return &rpc.State{Type: "Map", Value: entries}, nil
+	default:
+		return nil, fmt.Errorf("%w %s", vm.ErrInvalidType, item.Type())
+	}
+
+	states := make([]*rpc.State, 0, len(items))
+
+	for _, field := range items {
+		state, err := toState(field, parents)
+
+		if err != nil {
+			return nil, err
+		}
+
+		states = append(states, state)
+	}
+
+	// structs are reported as arrays
+	return &rpc.State{Type: "Array", Value: states}, nil
+}
+
+func engineGetScriptContainer(service *Service, engine *vm.Engine) error {
+	if service.Container == nil {
+		return ErrNoScriptContainer
+	}
+
+	pushInterop(engine, service.Container)
+
+	return nil
+}
+
+func engineGetExecutingScriptHash(service *Service, engine *vm.Engine) error {
+	pushBytes(engine, engine.CurrentContext().ScriptHash())
+
+	return nil
+}
+
+func engineGetCallingScriptHash(service *Service, engine *vm.Engine) error {
+	var scriptHash []byte
+
+	if context := engine.CallingContext(); context != nil {
+		scriptHash = context.ScriptHash()
+	}
+
+	pushBytes(engine, scriptHash)
+
+	return nil
+}
+
+func engineGetEntryScriptHash(service *Service, engine *vm.Engine) error {
+	pushBytes(engine, engine.EntryContext().ScriptHash())
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package interop
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/inwecrypto/neogo/vm"
+)
+
+// MaxStorageKeySize max storage key size of neo 2.x
+const MaxStorageKeySize = 1024
+
+// StorageContext storage context of a contract, pushed as interop interface
+type StorageContext struct {
+	ScriptHash []byte // little endian contract script hash
+	ReadOnly   bool
+}
+
+// MemoryStorage in memory contract storage
+type MemoryStorage struct {
+	contracts map[string]map[string][]byte
+}
+
+// NewMemoryStorage create empty storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		contracts: make(map[string]map[string][]byte),
+	}
+}
+
+// Get get value of contract storage key
+func (storage *MemoryStorage) Get(scriptHash []byte, key []byte) ([]byte, bool) {
+	value, ok := storage.contracts[string(scriptHash)][string(key)]
+
+	return value, ok
+}
+
+// Put set value of contract storage key, e.g. to seed the storage before execution
+func (storage *MemoryStorage) Put(scriptHash []byte, key []byte, value []byte) {
+	items, ok := storage.contracts[string(scriptHash)]
+
+	if !ok {
+		items = make(map[string][]byte)
+		storage.contracts[string(scriptHash)] = items
+	}
+
+	items[string(key)] = append([]byte{}, value...)
+}
+
+// Delete delete contract storage key
+func (storage *MemoryStorage) Delete(scriptHash []byte, key []byte) {
+	items, ok := storage.contracts[string(scriptHash)]
+
+	if !ok {
+		return
+	}
+
+	delete(items, string(key))
+
+	if len(items) == 0 {
+		delete(storage.contracts, string(scriptHash))
+	}
+}
+
+// Keys get storage keys of contract in order
+func (storage *MemoryStorage) Keys(scriptHash []byte) [][]byte {
+	var keys []string
+
+	for key := range storage.contracts[string(scriptHash)] {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	result := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		result[i] = []byte(key)
+	}
+
+	return result
+}
+
+func registerStorage(service *Service) {
+	service.Register("Neo.Storage.GetContext", 1, storageGetContext)
+	service.Register("Neo.Storage.GetReadOnlyContext", 1, storageGetReadOnlyContext)
+	service.Register("Neo.StorageContext.AsReadOnly", 1, storageContextAsReadOnly)
+	service.Register("Neo.Storage.Get", 100, storageGet)
+	service.RegisterPriced("Neo.Storage.Put", storagePutPrice, storagePut)
+	service.Register("Neo.Storage.Delete", 100, storageDelete)
+}
+
+func storageGetContext(service *Service, engine *vm.Engine) error {
+	pushInterop(engine, &StorageContext{
+		ScriptHash: engine.CurrentContext().ScriptHash(),
+	})
+
+	return nil
+}
+
+func storageGetReadOnlyContext(service *Service, engine *vm.Engine) error {
+	pushInterop(engine, &StorageContext{
+		ScriptHash: engine.CurrentContext().ScriptHash(),
+		ReadOnly:   true,
+	})
+
+	return nil
+}
+
+func storageContextAsReadOnly(service *Service, engine *vm.Engine) error {
+	context, err := popStorageContext(engine)
+
+	if err != nil {
+		return err
+	}
+
+	pushInterop(engine, &StorageContext{
+		ScriptHash: context.ScriptHash,
+		ReadOnly:   true,
+	})
+
+	return nil
+}
+
+func storageGet(service *Service, engine *vm.Engine) error {
+	context, err := popStorageContext(engine)
+
+	if err != nil {
+		return err
+	}
+
+	key, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkStorage(context, false); err != nil {
+		return err
+	}
+
+	value, _ := service.Storage.Get(context.ScriptHash, key)
+
+	pushBytes(engine, value)
+
+	return nil
+}
+
+// storagePutPrice 1 GAS per KB of key and value, at least one KB
+func storagePutPrice(engine *vm.Engine) (int64, error) {
+	size := 0
+
+	// the storage context is on top of the key and value
+	for n := 1; n <= 2; n++ {
+		item, err := engine.EvaluationStack.Peek(n)
+
+		if err != nil {
+			return 0, err
+		}
+
+		data, err := item.Bytes()
+
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", vm.ErrInvalidType, err)
+		}
+
+		size += len(data)
+	}
+
+	return int64((size-1)/1024+1) * 1000, nil
+}
+
+func storagePut(service *Service, engine *vm.Engine) error {
+	context, err := popStorageContext(engine)
+
+	if err != nil {
+		return err
+	}
+
+	key, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	value, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	if len(key) > MaxStorageKeySize {
+		return ErrStorageKeySize
+	}
+
+	if err := service.checkStorage(context, true); err != nil {
+		return err
+	}
+
+	service.Storage.Put(context.ScriptHash, key, value)
+
+	return nil
+}
+
+func storageDelete(service *Service, engine *vm.Engine) error {
+	context, err := popStorageContext(engine)
+
+	if err != nil {
+		return err
+	}
+
+	key, err := popBytes(engine)
+
+	if err != nil {
+		return err
+	}
+
+	if err := service.checkStorage(context, true); err != nil {
+		return err
+	}
+
+	service.Storage.Delete(context.ScriptHash, key)
+
+	return nil
+}
+
+func popStorageContext(engine *vm.Engine) (*StorageContext, error) {
+	value, err := popInterop(engine)
+
+	if err != nil {
+		return nil, err
+	}
+
+	context, ok := value.(*StorageContext)
+
+	if !ok {
+		return nil, ErrInvalidInterop
+	}
+
+	return context, nil
+}
+
+// checkStorage contracts deployed to the chain snapshot must have storage, unknown contracts are allowed
+// so that scripts can run without deploying them
+func (service *Service) checkStorage(context *StorageContext, write bool) error {
+	if write {
+		if service.Trigger != TriggerApplication {
+			return ErrTrigger
+		}
+
+		if context.ReadOnly {
+			return ErrReadOnly
+		}
+	}
+
+	if contract, ok := service.Chain.Contract(context.ScriptHash); ok && !contract.HasStorage {
+		return ErrNoStorage
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Fixed8 errors
+var (
+	ErrFixed8Overflow = errors.New("fixed8 overflow")
+	ErrFixed8DivZero  = errors.New("fixed8 divide by zero")
+)
+
+const fixed8Decimals = 100000000
+
+var bigFixed8Decimals = big.NewInt(fixed8Decimals)
+
+// Fixed8 fixed point number with 8 decimals
+type Fixed8 int64
+
+// MakeFixed8 convert float64 to fixed8, the value is rounded to 8 decimals,
+// use ParseFixed8 when the exact decimal string is available
+func MakeFixed8(val float64) Fixed8 {
+	fixed8, _ := ParseFixed8(strconv.FormatFloat(val, 'f', 8, 64))
+
+	return fixed8
+}
+
+// ParseFixed8 parse decimal string, e.g "10.5" or "0.00013874",
+// more than 8 decimals is an error instead of silent rounding
+func ParseFixed8(val string) (Fixed8, error) {
+	val = strings.TrimSpace(val)
+
+	if val == "" || strings.Contains(val, "/") {
+		return 0, fmt.Errorf("invalid fixed8 string '%s'", val)
+	}
+
+	rat, ok := new(big.Rat).SetString(val)
+
+	if !ok {
+		return 0, fmt.Errorf("invalid fixed8 string '%s'", val)
+	}
+
+	rat.Mul(rat, new(big.Rat).SetInt(bigFixed8Decimals))
+
+	if !rat.IsInt() {
+		return 0, fmt.Errorf("fixed8 string '%s' has more than 8 decimals", val)
+	}
+
+	if !rat.Num().IsInt64() {
+		return 0, ErrFixed8Overflow
+	}
+
+	return Fixed8(rat.Num().Int64()), nil
+}
+
+// Int convert to big.Int object
+func (fixed8 Fixed8) Int() *big.Int {
+	return big.NewInt(int64(fixed8))
+}
+
+func (fixed8 *Fixed8) Write(writer io.Writer) error {
+	data := make([]byte, 8)
+
+	binary.LittleEndian.PutUint64(data, uint64(*fixed8))
+
+	_, err := writer.Write(data)
+
+	return err
+}
+
+func (fixed8 *Fixed8) Read(reader io.Reader) error {
+
+	data := make([]byte, 8)
+
+	_, err := io.ReadFull(reader, data)
+
+	if err != nil {
+		return err
+	}
+
+	*fixed8 = Fixed8(binary.LittleEndian.Uint64(data))
+
+	return nil
+}
+
+// Float64 convert fixe8 to float64, only for display
+func (fixed8 Fixed8) Float64() float64 {
+	r, _ := strconv.ParseFloat(fixed8.String(), 64)
+
+	return r
+}
+
+// String exact decimal string with 8 decimals, e.g. "1.50000000", see Trimmed
+func (fixed8 Fixed8) String() string {
+	sign, integer, fraction := fixed8.decimal()
+
+	return sign + integer + "." + fraction
+}
+
+// Trimmed exact decimal string without trailing zeros, e.g. "1.5", the format of neo rpc results
+func (fixed8 Fixed8) Trimmed() string {
+	sign, integer, fraction := fixed8.decimal()
+
+	fraction = strings.TrimRight(fraction, "0")
+
+	if fraction == "" {
+		return sign + integer
+	}
+
+	return sign + integer + "." + fraction
+}
+
+func (fixed8 Fixed8) decimal() (sign string, integer string, fraction string) {
+	abs := uint64(fixed8)
+
+	if fixed8 < 0 {
+		sign = "-"
+		abs = uint64(-fixed8)
+	}
+
+	integer = strconv.FormatUint(abs/fixed8Decimals, 10)
+	fraction = fmt.Sprintf("%08d", abs%fixed8Decimals)
+
+	return
+}
+
+// Add return fixed8 + other
+func (fixed8 Fixed8) Add(other Fixed8) (Fixed8, error) {
+	if (other > 0 && fixed8 > math.MaxInt64-other) || (other < 0 && fixed8 < math.MinInt64-other) {
+		return 0, ErrFixed8Overflow
+	}
+
+	return fixed8 + other, nil
+}
+
+// Sub return fixed8 - other
+func (fixed8 Fixed8) Sub(other Fixed8) (Fixed8, error) {
+	if (other < 0 && fixed8 > math.MaxInt64+other) || (other > 0 && fixed8 < math.MinInt64+other) {
+		return 0, ErrFixed8Overflow
+	}
+
+	return fixed8 - other, nil
+}
+
+// Mul return fixed8 * other, decimals after the 8th are truncated
+func (fixed8 Fixed8) Mul(other Fixed8) (Fixed8, error) {
+	result := new(big.Int).Mul(fixed8.Int(), other.Int())
+
+	result.Quo(result, bigFixed8Decimals)
+
+	if !result.IsInt64() {
+		return 0, ErrFixed8Overflow
+	}
+
+	return Fixed8(result.Int64()), nil
+}
+
+// Div return fixed8 / other, decimals after the 8th are truncated
+func (fixed8 Fixed8) Div(other Fixed8) (Fixed8, error) {
+	if other == 0 {
+		return 0, ErrFixed8DivZero
+	}
+
+	result := new(big.Int).Mul(fixed8.Int(), bigFixed8Decimals)
+
+	result.Quo(result, other.Int())
+
+	if !result.IsInt64() {
+		return 0, ErrFixed8Overflow
+	}
+
+	return Fixed8(result.Int64()), nil
+}
+
+// Cmp compare fixed8 and other, return -1 if fixed8 < other, 0 if equal, +1 if fixed8 > other
+func (fixed8 Fixed8) Cmp(other Fixed8) int {
+	if fixed8 < other {
+		return -1
+	}
+
+	if fixed8 > other {
+		return 1
+	}
+
+	return 0
+}
+
+// MarshalText implement encoding.TextMarshaler
+func (fixed8 Fixed8) MarshalText() ([]byte, error) {
+	return []byte(fixed8.Trimmed()), nil
+}
+
+// UnmarshalText implement encoding.TextUnmarshaler
+func (fixed8 *Fixed8) UnmarshalText(text []byte) error {
+	val, err := ParseFixed8(string(text))
+
+	if err != nil {
+		return err
+	}
+
+	*fixed8 = val
+
+	return nil
+}
+
+// MarshalJSON marshal as decimal string
+func (fixed8 Fixed8) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(fixed8.Trimmed())), nil
+}
+
+// UnmarshalJSON accept both decimal string and json number
+func (fixed8 *Fixed8) UnmarshalJSON(data []byte) error {
+	text := string(data)
+
+	if text == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	return fixed8.UnmarshalText([]byte(text))
+}
@@ -3,7 +3,6 @@ package rpc
 import (
 	"bytes"
 	"encoding/hex"
-	"strconv"
 )
 
 // Value .
@@ -117,7 +116,7 @@ type Vout struct {
 	Address string `json:"Address"`
 	Asset   string `json:"Asset"`
 	N       int    `json:"N"`
-	Value   string `json:"Value"`
+	Value   Fixed8 `json:"Value"`
 }
 
 // Vin .
@@ -158,25 +157,12 @@ type UTXO struct {
 	SpentTime     string `json:"spentTime"`
 	Block         int64  `json:"block"`
 	SpentBlock    int64  `json:"spentBlock"`
-	value         *float64
 	Gas           string `json:"gas"`
 }
 
 // Value get utxo value
-func (utxo *UTXO) Value() (float64, error) {
-
-	if utxo.value == nil {
-
-		val, err := strconv.ParseFloat(utxo.Vout.Value, 64)
-
-		if err != nil {
-			return 0, err
-		}
-
-		utxo.value = &val
-	}
-
-	return *utxo.value, nil
+func (utxo *UTXO) Value() Fixed8 {
+	return utxo.Vout.Value
 }
 
 // TxHex get utxo txid hex value
@@ -195,10 +181,7 @@ func (a utxoByValue) Swap(i, j int) {
 
 func (a utxoByValue) Less(i, j int) bool {
 
-	vali, _ := a[1].Value()
-	valj, _ := a[1].Value()
-
-	return vali < valj
+	return a[i].Value().Cmp(a[j].Value()) < 0
 }
 
 // TxAttr .
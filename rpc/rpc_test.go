@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"testing"
 
@@ -179,3 +180,68 @@ func TestConvert(t *testing.T) {
 
 	println(fmt.Sprintf("%d", new(big.Int).SetBytes(data)))
 }
+
+func TestFixed8(t *testing.T) {
+	val, err := ParseFixed8("0.00013874")
+
+	require.NoError(t, err)
+	require.Equal(t, Fixed8(13874), val)
+	require.Equal(t, "0.00013874", val.Trimmed())
+
+	val, err = ParseFixed8("-10000.416")
+
+	require.NoError(t, err)
+	require.Equal(t, Fixed8(-1000041600000), val)
+	require.Equal(t, "-10000.416", val.Trimmed())
+
+	// String keeps the fixed 8 decimals format
+	require.Equal(t, "-10000.41600000", val.String())
+	require.Equal(t, "5.00000000", MakeFixed8(5).String())
+	require.Equal(t, "5", MakeFixed8(5).Trimmed())
+
+	_, err = ParseFixed8("0.000000001")
+	require.Error(t, err)
+
+	_, err = ParseFixed8("100000000000")
+	require.Equal(t, ErrFixed8Overflow, err)
+
+	// 0.1 + 0.2 must be exactly 0.3
+	a, _ := ParseFixed8("0.1")
+	b, _ := ParseFixed8("0.2")
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	require.Equal(t, "0.3", sum.Trimmed())
+
+	_, err = Fixed8(math.MaxInt64).Add(1)
+	require.Equal(t, ErrFixed8Overflow, err)
+
+	_, err = Fixed8(math.MinInt64).Sub(1)
+	require.Equal(t, ErrFixed8Overflow, err)
+
+	product, err := MakeFixed8(1.5).Mul(MakeFixed8(3))
+	require.NoError(t, err)
+	require.Equal(t, "4.5", product.Trimmed())
+
+	quotient, err := MakeFixed8(1).Div(MakeFixed8(3))
+	require.NoError(t, err)
+	require.Equal(t, "0.33333333", quotient.Trimmed())
+
+	_, err = quotient.Div(0)
+	require.Equal(t, ErrFixed8DivZero, err)
+
+	require.Equal(t, -1, a.Cmp(b))
+	require.Equal(t, 0, a.Cmp(a))
+
+	var vout Vout
+
+	require.NoError(t, json.Unmarshal([]byte(`{"Value":"2.00000001"}`), &vout))
+	require.Equal(t, Fixed8(200000001), vout.Value)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"Value":0.5}`), &vout))
+	require.Equal(t, Fixed8(50000000), vout.Value)
+
+	data, err := json.Marshal(&vout)
+	require.NoError(t, err)
+	require.Equal(t, `{"Address":"","Asset":"","N":0,"Value":"0.5"}`, string(data))
+}
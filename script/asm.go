@@ -0,0 +1,444 @@
+package script
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError assembler error with the source line number
+type SyntaxError struct {
+	Line int    // 1-based line number
+	Text string // source line
+	Err  error  // underlying error
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", err.Line, err.Err, strings.TrimSpace(err.Text))
+}
+
+// Unwrap get the underlying error
+func (err *SyntaxError) Unwrap() error {
+	return err.Err
+}
+
+var mnemonics = map[string]OpCode{
+	"PUSHT": PUSHT,
+	"PUSHF": PUSHF,
+}
+
+func init() {
+	for _, info := range opInfos {
+		mnemonics[info.Name] = info.Code
+	}
+}
+
+type labelRef struct {
+	op    *Op
+	index int // op index
+	label string
+	line  int
+	text  string
+}
+
+// Assemble assemble NeoVM assembly source, one instruction per line:
+//
+//	; comments start with ; or //
+//	start:                     label, jump and CALL target
+//	    PUSH 100               push integer, "string", 0xhex bytes, true or false
+//	    JMPIFNOT end           jump to label, relative offsets are resolved by the assembler
+//	    APPCALL 0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9
+//	    SYSCALL Neo.Runtime.Notify
+//	end:
+//	    RET
+//
+// mnemonics are the op names, PUSHBYTES/PUSHDATA take hex data, APPCALL/TAILCALL take big-endian script hash,
+// CALL_I takes return count, parameter count and label, CALL_E/CALL_ET take the counts and script hash
+func Assemble(source string) (*Script, error) {
+	script := New("asm")
+
+	labels := make(map[string]int)
+
+	var refs []*labelRef
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := scanner.Text()
+
+		fail := func(err error) (*Script, error) {
+			return nil, &SyntaxError{Line: line, Text: text, Err: err}
+		}
+
+		fields, err := tokenize(text)
+
+		if err != nil {
+			return fail(err)
+		}
+
+		for len(fields) > 0 && strings.HasSuffix(fields[0], ":") {
+			label := strings.TrimSuffix(fields[0], ":")
+
+			if !isIdentifier(label) {
+				return fail(fmt.Errorf("invalid label %s", label))
+			}
+
+			if _, ok := labels[label]; ok {
+				return fail(fmt.Errorf("duplicate label %s", label))
+			}
+
+			labels[label] = len(script.Ops)
+
+			fields = fields[1:]
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		ref, err := assembleInstruction(script, fields)
+
+		if err != nil {
+			return fail(err)
+		}
+
+		if script.Error != nil {
+			return fail(script.Error)
+		}
+
+		if ref != nil {
+			ref.index = len(script.Ops) - 1
+			ref.op = script.Ops[ref.index]
+			ref.line = line
+			ref.text = text
+			refs = append(refs, ref)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	offsets := make([]int, len(script.Ops)+1)
+
+	for i, op := range script.Ops {
+		offsets[i+1] = offsets[i] + op.Size()
+	}
+
+	for _, ref := range refs {
+		index, ok := labels[ref.label]
+
+		if !ok {
+			return nil, &SyntaxError{Line: ref.line, Text: ref.text, Err: fmt.Errorf("undefined label %s", ref.label)}
+		}
+
+		offset := offsets[index] - offsets[ref.index]
+
+		if offset < math.MinInt16 || offset > math.MaxInt16 {
+			return nil, &SyntaxError{Line: ref.line, Text: ref.text, Err: fmt.Errorf("label %s out of jump range", ref.label)}
+		}
+
+		// the jump offset is the last operand of JMP, CALL and CALL_I
+		binary.LittleEndian.PutUint16(ref.op.Arg[len(ref.op.Arg)-2:], uint16(int16(offset)))
+	}
+
+	return script, nil
+}
+
+func assembleInstruction(script *Script, fields []string) (*labelRef, error) {
+	mnemonic := strings.ToUpper(fields[0])
+
+	args := fields[1:]
+
+	if mnemonic == "PUSH" {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("PUSH expect one literal")
+		}
+
+		return nil, pushLiteral(script, args[0])
+	}
+
+	code, ok := mnemonics[mnemonic]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown mnemonic %s", fields[0])
+	}
+
+	switch {
+	case code == JMP || code == JMPIF || code == JMPIFNOT || code == CALL:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expect label or offset", code)
+		}
+
+		if isIdentifier(args[0]) {
+			script.EmitJump(code, 0)
+
+			return &labelRef{label: args[0]}, nil
+		}
+
+		offset, err := strconv.ParseInt(args[0], 0, 16)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid jump target %s", args[0])
+		}
+
+		script.EmitJump(code, int16(offset))
+
+		return nil, nil
+	case code == APPCALL || code == TAILCALL:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expect script hash", code)
+		}
+
+		scriptHash, err := parseHex(args[0])
+
+		if err != nil || len(scriptHash) != 20 {
+			return nil, fmt.Errorf("invalid script hash %s", args[0])
+		}
+
+		script.EmitAPPCall(reverseBytes(scriptHash), code == TAILCALL)
+
+		return nil, nil
+	case code >= CALL_I && code <= CALL_EDT:
+		return assembleCall(script, code, args)
+	case code == SYSCALL:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("SYSCALL expect api name")
+		}
+
+		api := args[0]
+
+		if strings.HasPrefix(api, `"`) {
+			var err error
+
+			if api, err = strconv.Unquote(api); err != nil {
+				return nil, fmt.Errorf("invalid api name %s", args[0])
+			}
+		}
+
+		script.EmitSysCall(api)
+
+		return nil, nil
+	case code >= PUSHBYTES1 && code <= PUSHDATA4:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expect hex data", code)
+		}
+
+		data, err := parseHex(args[0])
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex data %s", args[0])
+		}
+
+		if code <= PUSHBYTES75 {
+			if len(data) != int(code) {
+				return nil, fmt.Errorf("%s expect %d bytes, got %d", code, code, len(data))
+			}
+
+			script.Emit(code, data)
+
+			return nil, nil
+		}
+
+		return nil, pushData(script, code, data)
+	}
+
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%s takes no operand", code)
+	}
+
+	script.Emit(code, nil)
+
+	return nil, nil
+}
+
+// assembleCall CALL_I rvcount pcount label, CALL_E and CALL_ET rvcount pcount scripthash, CALL_ED and CALL_EDT rvcount pcount
+func assembleCall(script *Script, code OpCode, args []string) (*labelRef, error) {
+	expect := 2
+
+	if code == CALL_I || code == CALL_E || code == CALL_ET {
+		expect = 3
+	}
+
+	if len(args) != expect {
+		return nil, fmt.Errorf("%s expect %d operands", code, expect)
+	}
+
+	arg := make([]byte, 2)
+
+	for i := range arg {
+		count, err := strconv.ParseUint(args[i], 0, 8)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s count %s", code, args[i])
+		}
+
+		arg[i] = byte(count)
+	}
+
+	switch code {
+	case CALL_I:
+		if isIdentifier(args[2]) {
+			script.Emit(code, append(arg, 0, 0))
+
+			return &labelRef{label: args[2]}, nil
+		}
+
+		offset, err := strconv.ParseInt(args[2], 0, 16)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid jump target %s", args[2])
+		}
+
+		arg = append(arg, 0, 0)
+
+		binary.LittleEndian.PutUint16(arg[2:], uint16(int16(offset)))
+	case CALL_E, CALL_ET:
+		scriptHash, err := parseHex(args[2])
+
+		if err != nil || len(scriptHash) != 20 {
+			return nil, fmt.Errorf("invalid script hash %s", args[2])
+		}
+
+		arg = append(arg, reverseBytes(scriptHash)...)
+	}
+
+	script.Emit(code, arg)
+
+	return nil, nil
+}
+
+func pushData(script *Script, code OpCode, data []byte) error {
+	var prefix []byte
+
+	switch code {
+	case PUSHDATA1:
+		if len(data) > math.MaxUint8 {
+			return fmt.Errorf("PUSHDATA1 data exceeds %d bytes", math.MaxUint8)
+		}
+
+		prefix = []byte{byte(len(data))}
+	case PUSHDATA2:
+		if len(data) > math.MaxUint16 {
+			return fmt.Errorf("PUSHDATA2 data exceeds %d bytes", math.MaxUint16)
+		}
+
+		prefix = make([]byte, 2)
+
+		binary.LittleEndian.PutUint16(prefix, uint16(len(data)))
+	default:
+		prefix = make([]byte, 4)
+
+		binary.LittleEndian.PutUint32(prefix, uint32(len(data)))
+	}
+
+	script.Emit(code, append(prefix, data...))
+
+	return nil
+}
+
+func pushLiteral(script *Script, literal string) error {
+	switch {
+	case literal == "true" || literal == "false":
+		script.EmitPushBool(literal == "true")
+	case strings.HasPrefix(literal, `"`):
+		value, err := strconv.Unquote(literal)
+
+		if err != nil {
+			return fmt.Errorf("invalid string literal %s", literal)
+		}
+
+		script.EmitPushString(value)
+	case strings.HasPrefix(literal, "0x") || strings.HasPrefix(literal, "0X"):
+		data, err := parseHex(literal)
+
+		if err != nil {
+			return fmt.Errorf("invalid hex literal %s", literal)
+		}
+
+		script.EmitPushBytes(data)
+	default:
+		number, ok := new(big.Int).SetString(literal, 10)
+
+		if !ok {
+			return fmt.Errorf("invalid literal %s", literal)
+		}
+
+		script.EmitPushInteger(number)
+	}
+
+	return nil
+}
+
+func parseHex(data string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(data, "0x"), "0X"))
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// tokenize split line by spaces, quoted strings are kept as one token and comments are dropped
+func tokenize(line string) ([]string, error) {
+	var fields []string
+
+	for i := 0; i < len(line); {
+		c := line[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == ',':
+			i++
+		case c == ';' || strings.HasPrefix(line[i:], "//"):
+			return fields, nil
+		case c == '"':
+			end := i + 1
+
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+
+			fields = append(fields, line[i:end+1])
+
+			i = end + 1
+		default:
+			end := i
+
+			for end < len(line) && !strings.ContainsRune(" \t,;\"", rune(line[end])) && !strings.HasPrefix(line[end:], "//") {
+				end++
+			}
+
+			fields = append(fields, line[i:end])
+
+			i = end
+		}
+	}
+
+	return fields, nil
+}
@@ -3,6 +3,7 @@ package script
 import (
 	"encoding/hex"
 	"fmt"
+	"sort"
 )
 
 // OpCode script opcode
@@ -100,6 +101,7 @@ const (
 	HASH160                = 0xA9
 	HASH256                = 0xAA
 	CHECKSIG               = 0xAC
+	VERIFY                 = 0xAD
 	CHECKMULTISIG          = 0xAE
 	ARRAYSIZE              = 0xC0
 	PACK                   = 0xC1
@@ -108,109 +110,186 @@ const (
 	SETITEM                = 0xC4
 	NEWARRAY               = 0xC5 //用作引用類型
 	NEWSTRUCT              = 0xC6 //用作值類型
+	NEWMAP                 = 0xC7
+	APPEND                 = 0xC8
+	REVERSE                = 0xC9
+	REMOVE                 = 0xCA
+	HASKEY                 = 0xCB
+	KEYS                   = 0xCC
+	VALUES                 = 0xCD
+	CALL_I                 = 0xE0 // Calls a subroutine of the script, operands are return count, parameter count and jump offset.
+	CALL_E                 = 0xE1 // Calls a contract, operands are return count, parameter count and script hash.
+	CALL_ED                = 0xE2 // Calls a contract whose script hash is taken from the stack.
+	CALL_ET                = 0xE3 // Tail call of CALL_E.
+	CALL_EDT               = 0xE4 // Tail call of CALL_ED.
 	THROW                  = 0xF0
 	THROWIFNOT             = 0xF1
 )
 
-var op2Strings = map[OpCode]string{
-	PUSH0:           "PUSH0      ",
-	PUSHBYTES1:      "PUSHBYTES1 ",
-	PUSHBYTES75:     "PUSHBYTES75",
-	PUSHDATA1:       "PUSHDATA1  ",
-	PUSHDATA2:       "PUSHDATA2  ",
-	PUSHDATA4:       "PUSHDATA4  ",
-	PUSHM1:          "PUSHM1     ",
-	PUSH1:           "PUSH1      ",
-	PUSH2:           "PUSH2      ",
-	PUSH3:           "PUSH3      ",
-	PUSH4:           "PUSH4      ",
-	PUSH5:           "PUSH5      ",
-	PUSH6:           "PUSH6      ",
-	PUSH7:           "PUSH7      ",
-	PUSH8:           "PUSH8      ",
-	PUSH9:           "PUSH9      ",
-	PUSH10:          "PUSH10     ",
-	PUSH11:          "PUSH11     ",
-	PUSH12:          "PUSH12     ",
-	PUSH13:          "PUSH13     ",
-	PUSH14:          "PUSH14     ",
-	PUSH15:          "PUSH15     ",
-	PUSH16:          "PUSH16     ",
-	NOP:             "NOP        ",
-	JMP:             "JMP        ",
-	JMPIF:           "JMPIF      ",
-	JMPIFNOT:        "JMPIFNOT   ",
-	CALL:            "CALL       ",
-	RET:             "RET        ",
-	APPCALL:         "APPCALL    ",
-	SYSCALL:         "SYSCALL    ",
-	TAILCALL:        "TAILCALL   ",
-	DUPFROMALTSTACK: "DUPFROMALTS",
-	TOALTSTACK:      "TOALTSTACK ",
-	FROMALTSTACK:    "FROMALTSTAC",
-	XDROP:           "XDROP      ",
-	XSWAP:           "XSWAP      ",
-	XTUCK:           "XTUCK      ",
-	DEPTH:           "DEPTH      ",
-	DROP:            "DROP       ",
-	DUP:             "DUP        ",
-	NIP:             "NIP        ",
-	OVER:            "OVER       ",
-	PICK:            "PICK       ",
-	ROLL:            "ROLL       ",
-	ROT:             "ROT        ",
-	SWAP:            "SWAP       ",
-	TUCK:            "TUCK       ",
-	CAT:             "CAT        ",
-	SUBSTR:          "SUBSTR     ",
-	LEFT:            "LEFT       ",
-	RIGHT:           "RIGHT      ",
-	SIZE:            "SIZE       ",
-	INVERT:          "INVERT     ",
-	AND:             "AND        ",
-	OR:              "OR         ",
-	XOR:             "XOR        ",
-	EQUAL:           "EQUAL      ",
-	INC:             "INC        ",
-	DEC:             "DEC        ",
-	SIGN:            "SIGN       ",
-	NEGATE:          "NEGATE     ",
-	ABS:             "ABS        ",
-	NOT:             "NOT        ",
-	NZ:              "NZ         ",
-	ADD:             "ADD        ",
-	SUB:             "SUB        ",
-	MUL:             "MUL        ",
-	DIV:             "DIV        ",
-	MOD:             "MOD        ",
-	SHL:             "SHL        ",
-	SHR:             "SHR        ",
-	BOOLAND:         "BOOLAND    ",
-	BOOLOR:          "BOOLOR     ",
-	NUMEQUAL:        "NUMEQUAL   ",
-	NUMNOTEQUAL:     "NUMNOTEQUAL",
-	LT:              "LT         ",
-	GT:              "GT         ",
-	LTE:             "LTE        ",
-	GTE:             "GTE        ",
-	MIN:             "MIN        ",
-	MAX:             "MAX        ",
-	WITHIN:          "WITHIN     ",
-	SHA1:            "SHA1       ",
-	SHA256:          "SHA256     ",
-	HASH160:         "HASH160    ",
-	HASH256:         "HASH256    ",
-	CHECKSIG:        "CHECKSIG   ",
-	CHECKMULTISIG:   "CHECKMULTIS",
-	ARRAYSIZE:       "ARRAYSIZE  ",
-	PACK:            "PACK       ",
-	UNPACK:          "UNPACK     ",
-	PICKITEM:        "PICKITEM   ",
-	SETITEM:         "SETITEM    ",
-	NEWARRAY:        "NEWARRAY   ",
-	NEWSTRUCT:       "NEWSTRUCT  ",
-	THROW:           "THROW      ",
-	THROWIFNOT:      "THROWIFNOT ",
+// OpInfo opcode metadata
+type OpInfo struct {
+	Code        OpCode
+	Name        string
+	OperandSize int   // fixed operand size in bytes
+	PrefixSize  int   // size of the little endian length prefix of a variable operand, the operand includes the prefix
+	Flow        bool  // changes control flow
+	Price       int64 // gas units, CHECKMULTISIG costs the price per public key, SYSCALL adds the interop price
+}
+
+// namedOps opcodes except PUSHBYTES1 to PUSHBYTES75
+var namedOps = []OpInfo{
+	{Code: PUSH0, Name: "PUSH0", Price: 0},
+	{Code: PUSHDATA1, Name: "PUSHDATA1", PrefixSize: 1, Price: 0},
+	{Code: PUSHDATA2, Name: "PUSHDATA2", PrefixSize: 2, Price: 0},
+	{Code: PUSHDATA4, Name: "PUSHDATA4", PrefixSize: 4, Price: 0},
+	{Code: PUSHM1, Name: "PUSHM1", Price: 0},
+	{Code: PUSH1, Name: "PUSH1", Price: 0},
+	{Code: PUSH2, Name: "PUSH2", Price: 0},
+	{Code: PUSH3, Name: "PUSH3", Price: 0},
+	{Code: PUSH4, Name: "PUSH4", Price: 0},
+	{Code: PUSH5, Name: "PUSH5", Price: 0},
+	{Code: PUSH6, Name: "PUSH6", Price: 0},
+	{Code: PUSH7, Name: "PUSH7", Price: 0},
+	{Code: PUSH8, Name: "PUSH8", Price: 0},
+	{Code: PUSH9, Name: "PUSH9", Price: 0},
+	{Code: PUSH10, Name: "PUSH10", Price: 0},
+	{Code: PUSH11, Name: "PUSH11", Price: 0},
+	{Code: PUSH12, Name: "PUSH12", Price: 0},
+	{Code: PUSH13, Name: "PUSH13", Price: 0},
+	{Code: PUSH14, Name: "PUSH14", Price: 0},
+	{Code: PUSH15, Name: "PUSH15", Price: 0},
+	{Code: PUSH16, Name: "PUSH16", Price: 0},
+	{Code: NOP, Name: "NOP", Price: 0},
+	{Code: JMP, Name: "JMP", OperandSize: 2, Flow: true, Price: 1},
+	{Code: JMPIF, Name: "JMPIF", OperandSize: 2, Flow: true, Price: 1},
+	{Code: JMPIFNOT, Name: "JMPIFNOT", OperandSize: 2, Flow: true, Price: 1},
+	{Code: CALL, Name: "CALL", OperandSize: 2, Flow: true, Price: 1},
+	{Code: RET, Name: "RET", Flow: true, Price: 1},
+	{Code: APPCALL, Name: "APPCALL", OperandSize: 20, Flow: true, Price: 10},
+	{Code: SYSCALL, Name: "SYSCALL", PrefixSize: 1, Price: 1},
+	{Code: TAILCALL, Name: "TAILCALL", OperandSize: 20, Flow: true, Price: 10},
+	{Code: DUPFROMALTSTACK, Name: "DUPFROMALTSTACK", Price: 1},
+	{Code: TOALTSTACK, Name: "TOALTSTACK", Price: 1},
+	{Code: FROMALTSTACK, Name: "FROMALTSTACK", Price: 1},
+	{Code: XDROP, Name: "XDROP", Price: 1},
+	{Code: XSWAP, Name: "XSWAP", Price: 1},
+	{Code: XTUCK, Name: "XTUCK", Price: 1},
+	{Code: DEPTH, Name: "DEPTH", Price: 1},
+	{Code: DROP, Name: "DROP", Price: 1},
+	{Code: DUP, Name: "DUP", Price: 1},
+	{Code: NIP, Name: "NIP", Price: 1},
+	{Code: OVER, Name: "OVER", Price: 1},
+	{Code: PICK, Name: "PICK", Price: 1},
+	{Code: ROLL, Name: "ROLL", Price: 1},
+	{Code: ROT, Name: "ROT", Price: 1},
+	{Code: SWAP, Name: "SWAP", Price: 1},
+	{Code: TUCK, Name: "TUCK", Price: 1},
+	{Code: CAT, Name: "CAT", Price: 1},
+	{Code: SUBSTR, Name: "SUBSTR", Price: 1},
+	{Code: LEFT, Name: "LEFT", Price: 1},
+	{Code: RIGHT, Name: "RIGHT", Price: 1},
+	{Code: SIZE, Name: "SIZE", Price: 1},
+	{Code: INVERT, Name: "INVERT", Price: 1},
+	{Code: AND, Name: "AND", Price: 1},
+	{Code: OR, Name: "OR", Price: 1},
+	{Code: XOR, Name: "XOR", Price: 1},
+	{Code: EQUAL, Name: "EQUAL", Price: 1},
+	{Code: INC, Name: "INC", Price: 1},
+	{Code: DEC, Name: "DEC", Price: 1},
+	{Code: SIGN, Name: "SIGN", Price: 1},
+	{Code: NEGATE, Name: "NEGATE", Price: 1},
+	{Code: ABS, Name: "ABS", Price: 1},
+	{Code: NOT, Name: "NOT", Price: 1},
+	{Code: NZ, Name: "NZ", Price: 1},
+	{Code: ADD, Name: "ADD", Price: 1},
+	{Code: SUB, Name: "SUB", Price: 1},
+	{Code: MUL, Name: "MUL", Price: 1},
+	{Code: DIV, Name: "DIV", Price: 1},
+	{Code: MOD, Name: "MOD", Price: 1},
+	{Code: SHL, Name: "SHL", Price: 1},
+	{Code: SHR, Name: "SHR", Price: 1},
+	{Code: BOOLAND, Name: "BOOLAND", Price: 1},
+	{Code: BOOLOR, Name: "BOOLOR", Price: 1},
+	{Code: NUMEQUAL, Name: "NUMEQUAL", Price: 1},
+	{Code: NUMNOTEQUAL, Name: "NUMNOTEQUAL", Price: 1},
+	{Code: LT, Name: "LT", Price: 1},
+	{Code: GT, Name: "GT", Price: 1},
+	{Code: LTE, Name: "LTE", Price: 1},
+	{Code: GTE, Name: "GTE", Price: 1},
+	{Code: MIN, Name: "MIN", Price: 1},
+	{Code: MAX, Name: "MAX", Price: 1},
+	{Code: WITHIN, Name: "WITHIN", Price: 1},
+	{Code: SHA1, Name: "SHA1", Price: 10},
+	{Code: SHA256, Name: "SHA256", Price: 10},
+	{Code: HASH160, Name: "HASH160", Price: 20},
+	{Code: HASH256, Name: "HASH256", Price: 20},
+	{Code: CHECKSIG, Name: "CHECKSIG", Price: 100},
+	{Code: VERIFY, Name: "VERIFY", Price: 100},
+	{Code: CHECKMULTISIG, Name: "CHECKMULTISIG", Price: 100},
+	{Code: ARRAYSIZE, Name: "ARRAYSIZE", Price: 1},
+	{Code: PACK, Name: "PACK", Price: 1},
+	{Code: UNPACK, Name: "UNPACK", Price: 1},
+	{Code: PICKITEM, Name: "PICKITEM", Price: 1},
+	{Code: SETITEM, Name: "SETITEM", Price: 1},
+	{Code: NEWARRAY, Name: "NEWARRAY", Price: 1},
+	{Code: NEWSTRUCT, Name: "NEWSTRUCT", Price: 1},
+	{Code: NEWMAP, Name: "NEWMAP", Price: 1},
+	{Code: APPEND, Name: "APPEND", Price: 1},
+	{Code: REVERSE, Name: "REVERSE", Price: 1},
+	{Code: REMOVE, Name: "REMOVE", Price: 1},
+	{Code: HASKEY, Name: "HASKEY", Price: 1},
+	{Code: KEYS, Name: "KEYS", Price: 1},
+	{Code: VALUES, Name: "VALUES", Price: 1},
+	{Code: CALL_I, Name: "CALL_I", OperandSize: 4, Flow: true, Price: 1},
+	{Code: CALL_E, Name: "CALL_E", OperandSize: 22, Flow: true, Price: 1},
+	{Code: CALL_ED, Name: "CALL_ED", OperandSize: 2, Flow: true, Price: 1},
+	{Code: CALL_ET, Name: "CALL_ET", OperandSize: 22, Flow: true, Price: 1},
+	{Code: CALL_EDT, Name: "CALL_EDT", OperandSize: 2, Flow: true, Price: 1},
+	{Code: THROW, Name: "THROW", Flow: true, Price: 1},
+	{Code: THROWIFNOT, Name: "THROWIFNOT", Flow: true, Price: 1},
+}
+
+// opInfos and opTable are initialized before any init function, e.g. the mnemonics of the assembler
+var opInfos, opTable = newOpTable()
+
+func newOpTable() ([]OpInfo, map[OpCode]*OpInfo) {
+	infos := append([]OpInfo{}, namedOps...)
+
+	for code := PUSHBYTES1; code <= PUSHBYTES75; code++ {
+		infos = append(infos, OpInfo{
+			Code:        OpCode(code),
+			Name:        fmt.Sprintf("PUSHBYTES%d", code),
+			OperandSize: code,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Code < infos[j].Code
+	})
+
+	table := make(map[OpCode]*OpInfo)
+
+	for i := range infos {
+		table[infos[i].Code] = &infos[i]
+	}
+
+	return infos, table
+}
+
+// LookupOp get metadata of opcode, false for undefined opcodes
+func LookupOp(code OpCode) (OpInfo, bool) {
+	info, ok := opTable[code]
+
+	if !ok {
+		return OpInfo{}, false
+	}
+
+	return *info, true
+}
+
+// OpInfos get metadata of all defined opcodes in opcode order
+func OpInfos() []OpInfo {
+	return append([]OpInfo{}, opInfos...)
 }
 
 // Op .
@@ -220,5 +299,9 @@ type Op struct {
 }
 
 func (op *Op) String() string {
-	return fmt.Sprintf("%s\n%s", op2Strings[op.Code], hex.EncodeToString(op.Arg))
+	if len(op.Arg) == 0 {
+		return op.Code.String()
+	}
+
+	return fmt.Sprintf("%s %s", op.Code, hex.EncodeToString(op.Arg))
 }
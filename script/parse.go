@@ -0,0 +1,282 @@
+package script
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"unicode"
+)
+
+// MaxSysCallNameSize max length of SYSCALL api name
+const MaxSysCallNameSize = 252
+
+// String opcode name
+func (code OpCode) String() string {
+	if info, ok := opTable[code]; ok {
+		return info.Name
+	}
+
+	return fmt.Sprintf("0x%02X", byte(code))
+}
+
+// Parse decode script bytecode into ops, the operands are kept as emitted
+// (PUSHDATA and SYSCALL operands include the length prefix) so the ops write back to the same bytes
+func Parse(code []byte) ([]*Op, error) {
+	var ops []*Op
+
+	for offset := 0; offset < len(code); {
+		op, err := ParseOp(code, offset)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ops = append(ops, op)
+
+		offset += op.Size()
+	}
+
+	return ops, nil
+}
+
+// ParseOp decode the op at offset, the operand shares memory with code
+func ParseOp(code []byte, offset int) (*Op, error) {
+	if offset < 0 || offset >= len(code) {
+		return nil, fmt.Errorf("[%04x] offset out of script", offset)
+	}
+
+	opcode := OpCode(code[offset])
+
+	size, err := operandSize(opcode, code[offset+1:])
+
+	if err != nil {
+		return nil, fmt.Errorf("[%04x] %s %s", offset, opcode, err)
+	}
+
+	return &Op{
+		Code: opcode,
+		Arg:  code[offset+1 : offset+1+size],
+	}, nil
+}
+
+// operandSize get operand size of opcode from the opcode table, rest is the bytes after the opcode,
+// undefined opcodes have no operand
+func operandSize(opcode OpCode, rest []byte) (int, error) {
+	prefix := 0
+	size := 0
+
+	if info, ok := opTable[opcode]; ok {
+		prefix = info.PrefixSize
+		size = info.OperandSize
+	}
+
+	if len(rest) < prefix {
+		return 0, fmt.Errorf("length prefix truncated")
+	}
+
+	switch prefix {
+	case 1:
+		size = int(rest[0])
+	case 2:
+		size = int(binary.LittleEndian.Uint16(rest))
+	case 4:
+		length := binary.LittleEndian.Uint32(rest)
+
+		if uint64(length) > uint64(len(rest)) {
+			return 0, fmt.Errorf("operand truncated, expect %d bytes", length)
+		}
+
+		size = int(length)
+	}
+
+	if opcode == SYSCALL && (size == 0 || size > MaxSysCallNameSize) {
+		return 0, fmt.Errorf("invalid api name length %d", size)
+	}
+
+	if len(rest) < prefix+size {
+		return 0, fmt.Errorf("operand truncated, expect %d bytes got %d", size, len(rest)-prefix)
+	}
+
+	return prefix + size, nil
+}
+
+// Size serialized op size in bytes
+func (op *Op) Size() int {
+	return 1 + len(op.Arg)
+}
+
+// Data get pushed data of PUSHBYTES and PUSHDATA ops
+func (op *Op) Data() ([]byte, bool) {
+	switch {
+	case op.Code >= PUSHBYTES1 && op.Code <= PUSHBYTES75:
+		return op.Arg, true
+	case op.Code == PUSHDATA1 && len(op.Arg) >= 1:
+		return op.Arg[1:], true
+	case op.Code == PUSHDATA2 && len(op.Arg) >= 2:
+		return op.Arg[2:], true
+	case op.Code == PUSHDATA4 && len(op.Arg) >= 4:
+		return op.Arg[4:], true
+	}
+
+	return nil, false
+}
+
+// JumpOffset get jump offset of JMP, JMPIF, JMPIFNOT, CALL and CALL_I, relative to the opcode
+func (op *Op) JumpOffset() (int16, bool) {
+	switch {
+	case (op.Code == JMP || op.Code == JMPIF || op.Code == JMPIFNOT || op.Code == CALL) && len(op.Arg) == 2:
+		return int16(binary.LittleEndian.Uint16(op.Arg)), true
+	case op.Code == CALL_I && len(op.Arg) == 4:
+		return int16(binary.LittleEndian.Uint16(op.Arg[2:])), true
+	}
+
+	return 0, false
+}
+
+// ScriptHash get called script hash of APPCALL, TAILCALL, CALL_E and CALL_ET
+func (op *Op) ScriptHash() ([]byte, bool) {
+	switch {
+	case (op.Code == APPCALL || op.Code == TAILCALL) && len(op.Arg) == 20:
+		return op.Arg, true
+	case (op.Code == CALL_E || op.Code == CALL_ET) && len(op.Arg) == 22:
+		return op.Arg[2:], true
+	}
+
+	return nil, false
+}
+
+// CallCounts get return value count and parameter count of CALL_I, CALL_E, CALL_ED, CALL_ET and CALL_EDT
+func (op *Op) CallCounts() (int, int, bool) {
+	if op.Code < CALL_I || op.Code > CALL_EDT || len(op.Arg) < 2 {
+		return 0, 0, false
+	}
+
+	return int(op.Arg[0]), int(op.Arg[1]), true
+}
+
+// SysCall get api name of SYSCALL
+func (op *Op) SysCall() (string, bool) {
+	if op.Code != SYSCALL || len(op.Arg) < 1 || len(op.Arg) != 1+int(op.Arg[0]) {
+		return "", false
+	}
+
+	return string(op.Arg[1:]), true
+}
+
+// Disassemble parse bytecode and print one op per line with byte offsets and readable operands
+func Disassemble(code []byte) (string, error) {
+	ops, err := Parse(code)
+
+	if err != nil {
+		return "", err
+	}
+
+	return FormatOps(ops), nil
+}
+
+// Disassemble print script ops, see FormatOps
+func (script *Script) Disassemble() string {
+	return FormatOps(script.Ops)
+}
+
+// FormatOps print ops one per line: offset, opcode name and operand,
+// jump targets are resolved to absolute offsets, APPCALL hashes are printed as big-endian script hash
+func FormatOps(ops []*Op) string {
+	offsets := make(map[int]bool)
+
+	offset := 0
+
+	for _, op := range ops {
+		offsets[offset] = true
+		offset += op.Size()
+	}
+
+	var buff bytes.Buffer
+
+	offset = 0
+
+	for _, op := range ops {
+		operand := formatOperand(op, offset, offsets)
+
+		if operand == "" {
+			fmt.Fprintf(&buff, "%04x  %s\n", offset, op.Code)
+		} else {
+			fmt.Fprintf(&buff, "%04x  %-12s %s\n", offset, op.Code, operand)
+		}
+
+		offset += op.Size()
+	}
+
+	return buff.String()
+}
+
+func formatOperand(op *Op, offset int, offsets map[int]bool) string {
+	if rvcount, pcount, ok := op.CallCounts(); ok {
+		operand := fmt.Sprintf("%d %d", rvcount, pcount)
+
+		// CALL_ED and CALL_EDT take the script hash from the stack
+		if target := formatTarget(op, offset, offsets); target != "" {
+			operand += " " + target
+		}
+
+		return operand
+	}
+
+	if data, ok := op.Data(); ok {
+		if isPrintable(data) {
+			return fmt.Sprintf("%s // %q", hex.EncodeToString(data), data)
+		}
+
+		return hex.EncodeToString(data)
+	}
+
+	if target := formatTarget(op, offset, offsets); target != "" {
+		return target
+	}
+
+	if api, ok := op.SysCall(); ok {
+		return fmt.Sprintf("%q", api)
+	}
+
+	if len(op.Arg) > 0 {
+		return hex.EncodeToString(op.Arg)
+	}
+
+	return ""
+}
+
+// formatTarget format jump target or called script hash, empty if op has neither
+func formatTarget(op *Op, offset int, offsets map[int]bool) string {
+	if jump, ok := op.JumpOffset(); ok {
+		target := offset + int(jump)
+
+		if !offsets[target] {
+			return fmt.Sprintf("%04x // invalid target, offset %d", target, jump)
+		}
+
+		return fmt.Sprintf("%04x", target)
+	}
+
+	if scriptHash, ok := op.ScriptHash(); ok {
+		hash := append([]byte{}, scriptHash...)
+
+		return "0x" + hex.EncodeToString(reverseBytes(hash))
+	}
+
+	return ""
+}
+
+func isPrintable(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	for _, c := range data {
+		if c >= unicode.MaxASCII || !unicode.IsPrint(rune(c)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -57,7 +57,7 @@ func (script *Script) Emit(opcode OpCode, arg []byte) *Script {
 // EmitJump .
 func (script *Script) EmitJump(op OpCode, offset int16) *Script {
 	if op != JMP && op != JMPIF && op != JMPIFNOT && op != CALL {
-		script.Error = fmt.Errorf("[%d] invalid EmitJump opcode %s", len(script.Ops), op2Strings[op])
+		script.Error = fmt.Errorf("[%d] invalid EmitJump opcode %s", len(script.Ops), op)
 		return script
 	}
 
@@ -86,24 +86,37 @@ func (script *Script) EmitAPPCall(scriptHash []byte, tailCall bool) *Script {
 
 // EmitPushInteger .
 func (script *Script) EmitPushInteger(number *big.Int) *Script {
-	if number.Int64() == -1 {
-		return script.Emit(PUSHM1, nil)
+	if number.IsInt64() && number.Int64() >= -1 && number.Int64() <= 16 {
+		switch number.Int64() {
+		case -1:
+			return script.Emit(PUSHM1, nil)
+		case 0:
+			return script.Emit(PUSH0, nil)
+		default:
+			return script.Emit(OpCode(byte(PUSH1)-1+byte(number.Int64())), nil)
+		}
 	}
 
-	if number.Int64() == 0 {
-		return script.Emit(PUSH0, nil)
-	}
+	// minimal little endian two's complement
+	if number.Sign() < 0 {
+		// -n is the inverted bits of n-1
+		data := reverseBytes(new(big.Int).Not(number).Bytes())
+
+		for i := range data {
+			data[i] = ^data[i]
+		}
+
+		if len(data) == 0 || data[len(data)-1]&0x80 == 0 {
+			data = append(data, 0xff)
+		}
 
-	if number.Int64() > 0 && number.Int64() <= 16 {
-		return script.Emit(OpCode(byte(PUSH1)-1+byte(number.Int64())), nil)
+		return script.EmitPushBytes(data)
 	}
 
 	data := reverseBytes(number.Bytes())
 
-	if number.Int64() > 0 {
+	if data[len(data)-1]&0x80 != 0 {
 		data = append(data, 0x00)
-	} else {
-		data = append(data, 0x80)
 	}
 
 	return script.EmitPushBytes(data)
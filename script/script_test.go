@@ -1,8 +1,10 @@
 package script
 
 import (
+	"bytes"
 	"encoding/hex"
 	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -19,3 +21,337 @@ func TestSignBigInt(t *testing.T) {
 
 	println(val.Int64(), val2.Int64())
 }
+
+func TestPushInteger(t *testing.T) {
+	large, _ := new(big.Int).SetString("18446744073709551616", 10)
+
+	for _, test := range []struct {
+		number *big.Int
+		code   string
+	}{
+		{big.NewInt(-1), "4f"},
+		{big.NewInt(0), "00"},
+		{big.NewInt(16), "60"},
+		{big.NewInt(17), "0111"},
+		{big.NewInt(127), "017f"},
+		{big.NewInt(128), "028000"},
+		{big.NewInt(-2), "01fe"},
+		{big.NewInt(-128), "0180"},
+		{big.NewInt(-129), "027fff"},
+		{big.NewInt(1000), "02e803"},
+		{big.NewInt(-1000), "0218fc"},
+		{big.NewInt(10000000), "0480969800"},
+		{large, "09000000000000000001"},
+		{new(big.Int).Neg(large), "090000000000000000ff"},
+	} {
+		code, err := New("push").EmitPushInteger(test.number).Bytes()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(code) != test.code {
+			t.Fatalf("push %s expect %s, got %x", test.number, test.code, code)
+		}
+	}
+}
+
+func TestParseOp(t *testing.T) {
+	code, _ := hex.DecodeString("0180c7adc8")
+
+	for _, test := range []struct {
+		offset int
+		code   OpCode
+		arg    string
+	}{
+		{0, PUSHBYTES1, "80"},
+		{2, NEWMAP, ""},
+		{3, VERIFY, ""},
+		{4, APPEND, ""},
+	} {
+		op, err := ParseOp(code, test.offset)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if op.Code != test.code || hex.EncodeToString(op.Arg) != test.arg {
+			t.Fatalf("[%04x] expect %s %s, got %s %x", test.offset, test.code, test.arg, op.Code, op.Arg)
+		}
+	}
+
+	if _, err := ParseOp(code, len(code)); err == nil {
+		t.Fatal("expect offset error")
+	}
+
+	if _, err := ParseOp([]byte{0x02, 0x01}, 0); err == nil {
+		t.Fatal("expect truncated operand error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	scriptHash, _ := hex.DecodeString("f91d6b7085db7c5aaf09f19eeec1ca3c0db2c6ec")
+
+	script := New("test")
+
+	script.
+		EmitPushInteger(big.NewInt(10000000)).
+		EmitPushBytes(bytes.Repeat([]byte{0x01}, 20)).
+		EmitPushBytes(bytes.Repeat([]byte{0x02}, 80)).
+		EmitPushBytes(bytes.Repeat([]byte{0x03}, 300)).
+		EmitPushString("transfer").
+		EmitJump(JMPIFNOT, 3).
+		Emit(NOP, nil).
+		EmitAPPCall(scriptHash, false).
+		EmitSysCall("Neo.Runtime.Notify").
+		Emit(RET, nil)
+
+	code, err := script.Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := Parse(code)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ops) != len(script.Ops) {
+		t.Fatalf("expect %d ops, got %d", len(script.Ops), len(ops))
+	}
+
+	var buff bytes.Buffer
+
+	for i, op := range ops {
+		if op.Code != script.Ops[i].Code || !bytes.Equal(op.Arg, script.Ops[i].Arg) {
+			t.Fatalf("op %d mismatch %s", i, op.Code)
+		}
+
+		buff.Write(append([]byte{byte(op.Code)}, op.Arg...))
+	}
+
+	if !bytes.Equal(buff.Bytes(), code) {
+		t.Fatal("parsed ops write different bytes")
+	}
+
+	if data, ok := ops[3].Data(); !ok || len(data) != 300 {
+		t.Fatal("PUSHDATA2 data mismatch")
+	}
+
+	if api, ok := ops[8].SysCall(); !ok || api != "Neo.Runtime.Notify" {
+		t.Fatalf("syscall mismatch %s", api)
+	}
+
+	text := script.Disassemble()
+
+	for _, line := range []string{
+		"0000  PUSHBYTES4   80969800",
+		"PUSHDATA1    " + strings.Repeat("02", 80),
+		"PUSHBYTES8   7472616e73666572 // \"transfer\"",
+		"01a4  JMPIFNOT     01a7",
+		"01a7  NOP",
+		"01a8  APPCALL      0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9",
+		"SYSCALL      \"Neo.Runtime.Notify\"",
+		"RET",
+	} {
+		if !strings.Contains(text, line) {
+			t.Fatalf("disassembly missing %s\n%s", line, text)
+		}
+	}
+
+	text, err = Disassemble([]byte{byte(JMP), 0x10, 0x00, byte(RET)})
+
+	if err != nil || !strings.Contains(text, "0010 // invalid target") {
+		t.Fatalf("invalid jump target not reported %s", text)
+	}
+
+	for _, invalid := range []string{"4c", "4d0100", "4e00000001", "0201", "6200", "6701", "6800", "6805414243"} {
+		data, _ := hex.DecodeString(invalid)
+
+		if _, err := Parse(data); err == nil {
+			t.Fatalf("expect error of %s", invalid)
+		}
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	source := `
+; count down from 3
+	PUSH 3
+loop:	DUP
+	JMPIFNOT end // exit when zero
+	DEC
+	JMP loop
+end:
+	DROP
+	PUSH "transfer"
+	PUSH 0x0102
+	PUSH true
+	PUSHBYTES2 0a0b
+	PUSHDATA1 ff
+	APPCALL 0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9
+	SYSCALL Neo.Runtime.Notify
+	syscall "Neo.Storage.Get"
+	CHECKMULTISIG
+	RET
+`
+
+	script, err := Assemble(source)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := script.Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := Parse(code)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JMPIFNOT at 2 jumps to end at 9, JMP at 6 jumps back to loop at 1
+	if offset, _ := ops[2].JumpOffset(); offset != 7 {
+		t.Fatalf("JMPIFNOT offset %d", offset)
+	}
+
+	if offset, _ := ops[4].JumpOffset(); offset != -5 {
+		t.Fatalf("JMP offset %d", offset)
+	}
+
+	text := FormatOps(ops)
+
+	for _, line := range []string{
+		"0002  JMPIFNOT     0009",
+		"0006  JMP          0001",
+		"0009  DROP",
+		"PUSHBYTES2   0102",
+		"PUSH1",
+		"PUSHDATA1    ff",
+		"APPCALL      0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9",
+		`SYSCALL      "Neo.Storage.Get"`,
+		"0057  CHECKMULTISIG",
+	} {
+		if !strings.Contains(text, line) {
+			t.Fatalf("assembly missing %s\n%s", line, text)
+		}
+	}
+
+	for source, line := range map[string]int{
+		"PUSH1\nJMP nowhere":                     2,
+		"PUSH1\n\nFOO":                           3,
+		"a:\na:":                                 2,
+		"PUSH \"unterminated":                    1,
+		"PUSH 12ab":                              1,
+		"PUSHBYTES2 01":                          1,
+		"APPCALL 0x01":                           1,
+		"RET 1":                                  1,
+		"PUSHDATA1 " + strings.Repeat("00", 256): 1,
+	} {
+		_, err := Assemble(source)
+
+		syntaxErr, ok := err.(*SyntaxError)
+
+		if !ok {
+			t.Fatalf("expect syntax error of %q, got %v", source, err)
+		}
+
+		if syntaxErr.Line != line {
+			t.Fatalf("expect error at line %d of %q, got %d", line, source, syntaxErr.Line)
+		}
+	}
+}
+
+func TestOpTable(t *testing.T) {
+	infos := OpInfos()
+
+	if len(infos) != 184 {
+		t.Fatalf("opcode count %d", len(infos))
+	}
+
+	for _, info := range infos {
+		if info.Code.String() != info.Name {
+			t.Fatalf("%s named %s", info.Name, info.Code)
+		}
+
+		if mnemonics[info.Name] != info.Code {
+			t.Fatalf("%s is not a mnemonic", info.Name)
+		}
+	}
+
+	for code, name := range map[OpCode]string{
+		DUPFROMALTSTACK: "DUPFROMALTSTACK",
+		FROMALTSTACK:    "FROMALTSTACK",
+		CHECKMULTISIG:   "CHECKMULTISIG",
+		CALL_EDT:        "CALL_EDT",
+		PUSHBYTES75:     "PUSHBYTES75",
+		0xAB:            "0xAB",
+	} {
+		if code.String() != name {
+			t.Fatalf("expect %s got %s", name, code)
+		}
+	}
+
+	if info, _ := LookupOp(CALL_E); info.OperandSize != 22 || !info.Flow || info.Price != 1 {
+		t.Fatalf("CALL_E metadata %+v", info)
+	}
+
+	if info, _ := LookupOp(SYSCALL); info.PrefixSize != 1 || info.Flow {
+		t.Fatalf("SYSCALL metadata %+v", info)
+	}
+
+	if _, ok := LookupOp(0x50); ok {
+		t.Fatal("0x50 is not an opcode")
+	}
+
+	op := &Op{Code: OpCode(0x02), Arg: []byte{1, 2}}
+
+	if op.String() != "PUSHBYTES2 0102" || (&Op{Code: RET}).String() != "RET" {
+		t.Fatalf("op string %q", op)
+	}
+
+	script, err := Assemble(`
+	PUSH 2
+	PUSH 3
+	CALL_I 1 2 add
+	CALL_E 1 0 0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9
+	CALL_EDT 0 1
+	RET
+add:
+	ADD
+	RET
+`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := script.Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := Disassemble(code)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"0002  CALL_I       1 2 0022",
+		"0007  CALL_E       1 0 0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9",
+		"001e  CALL_EDT     0 1",
+		"0022  ADD",
+	} {
+		if !strings.Contains(text, line) {
+			t.Fatalf("disassembly missing %s\n%s", line, text)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package tx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Attribute .
+type Attribute struct {
+	Usage byte
+	Data  []byte // ECDH02/ECDH03 data includes the public key prefix
+}
+
+// NewContractHashAttribute create ContractHash attribute
+func NewContractHashAttribute(hash []byte) (*Attribute, error) {
+	return newAttribute(ContractHash, hash)
+}
+
+// NewECDHAttribute create ECDH02 or ECDH03 attribute of compressed public key
+func NewECDHAttribute(publicKey []byte) (*Attribute, error) {
+	if len(publicKey) != 33 {
+		return nil, fmt.Errorf("invalid compressed public key %x", publicKey)
+	}
+
+	return newAttribute(publicKey[0], publicKey)
+}
+
+// NewScriptAttribute create Script attribute, the script hash is required to witness the transaction
+func NewScriptAttribute(scriptHash []byte) (*Attribute, error) {
+	return newAttribute(Script, scriptHash)
+}
+
+// NewVoteAttribute create Vote attribute
+func NewVoteAttribute(hash []byte) (*Attribute, error) {
+	return newAttribute(Vote, hash)
+}
+
+// NewDescriptionURLAttribute create DescriptionUrl attribute, the url is at most 255 bytes
+func NewDescriptionURLAttribute(url string) (*Attribute, error) {
+	return newAttribute(DescriptionURL, []byte(url))
+}
+
+// NewDescriptionAttribute create Description attribute
+func NewDescriptionAttribute(description []byte) (*Attribute, error) {
+	return newAttribute(Description, description)
+}
+
+// NewHashAttribute create Hash1-Hash15 attribute
+func NewHashAttribute(index int, hash []byte) (*Attribute, error) {
+	if index < 1 || index > 15 {
+		return nil, fmt.Errorf("invalid hash attribute index %d", index)
+	}
+
+	return newAttribute(Hash1+byte(index-1), hash)
+}
+
+// NewRemarkAttribute create Remark-Remark15 attribute, index 0 is Remark
+func NewRemarkAttribute(index int, remark []byte) (*Attribute, error) {
+	if index < 0 || index > 15 {
+		return nil, fmt.Errorf("invalid remark attribute index %d", index)
+	}
+
+	return newAttribute(Remark+byte(index), remark)
+}
+
+func newAttribute(usage byte, data []byte) (*Attribute, error) {
+	attr := &Attribute{
+		Usage: usage,
+		Data:  data,
+	}
+
+	if err := attr.Validate(); err != nil {
+		return nil, err
+	}
+
+	return attr, nil
+}
+
+func isHashUsage(usage byte) bool {
+	return usage == ContractHash || usage == Vote || (usage >= Hash1 && usage <= Hash15)
+}
+
+func isRemarkUsage(usage byte) bool {
+	return usage >= Remark
+}
+
+// Validate check usage and data length against the protocol rules
+func (attr *Attribute) Validate() error {
+	expect := -1
+	max := 0
+
+	switch {
+	case isHashUsage(attr.Usage):
+		expect = 32
+	case attr.Usage == ECDH02 || attr.Usage == ECDH03:
+		if len(attr.Data) != 33 || attr.Data[0] != attr.Usage {
+			return fmt.Errorf("invalid %s attribute public key %x", usageNames[attr.Usage], attr.Data)
+		}
+
+		return nil
+	case attr.Usage == Script:
+		expect = 20
+	case attr.Usage == DescriptionURL:
+		max = math.MaxUint8
+	case attr.Usage == Description || isRemarkUsage(attr.Usage):
+		max = math.MaxUint16
+	default:
+		return fmt.Errorf("unsupported attribute usage 0x%02x", attr.Usage)
+	}
+
+	if expect >= 0 && len(attr.Data) != expect {
+		return fmt.Errorf("%s attribute expect %d bytes, got %d", usageNames[attr.Usage], expect, len(attr.Data))
+	}
+
+	if expect < 0 && len(attr.Data) > max {
+		return fmt.Errorf("%s attribute exceeds %d bytes", usageNames[attr.Usage], max)
+	}
+
+	return nil
+}
+
+// UsageName get usage name as neo-cli prints
+func (attr *Attribute) UsageName() string {
+	if name, ok := usageNames[attr.Usage]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%02x", attr.Usage)
+}
+
+// Hash get the 32 bytes data of ContractHash, Vote and Hash1-Hash15 attributes
+func (attr *Attribute) Hash() ([]byte, bool) {
+	return attr.Data, isHashUsage(attr.Usage)
+}
+
+// ECDHPublicKey get compressed public key of ECDH02 and ECDH03 attributes
+func (attr *Attribute) ECDHPublicKey() ([]byte, bool) {
+	return attr.Data, attr.Usage == ECDH02 || attr.Usage == ECDH03
+}
+
+// ScriptHash get script hash of Script attribute
+func (attr *Attribute) ScriptHash() ([]byte, bool) {
+	return attr.Data, attr.Usage == Script
+}
+
+// DescriptionURL get url of DescriptionUrl attribute
+func (attr *Attribute) DescriptionURL() (string, bool) {
+	return string(attr.Data), attr.Usage == DescriptionURL
+}
+
+// Description get data of Description attribute
+func (attr *Attribute) Description() ([]byte, bool) {
+	return attr.Data, attr.Usage == Description
+}
+
+// Remark get data of Remark-Remark15 attributes
+func (attr *Attribute) Remark() ([]byte, bool) {
+	return attr.Data, isRemarkUsage(attr.Usage)
+}
+
+// JSON .
+func (attr *Attribute) JSON() string {
+	data, _ := json.Marshal(attr)
+
+	return string(data)
+}
+
+func (attr *Attribute) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
+
+	offset := r.Offset
+
+	attr.Usage = r.ReadU8("usage")
+
+	var body []byte
+
+	switch {
+	case isHashUsage(attr.Usage):
+		body = r.ReadBytes("data", 32)
+	case attr.Usage == Script:
+		body = r.ReadBytes("data", 20)
+	case attr.Usage == ECDH02 || attr.Usage == ECDH03:
+		body = append([]byte{attr.Usage}, r.ReadBytes("data", 32)...)
+	case attr.Usage == DescriptionURL:
+		body = r.ReadBytes("data", int(r.ReadU8("length")))
+	case attr.Usage == Description || isRemarkUsage(attr.Usage):
+		body = r.ReadVarBytes("data", math.MaxUint16)
+	default:
+		r.failAt(offset, "usage", fmt.Errorf("unsupported attribute usage 0x%02x", attr.Usage))
+	}
+
+	if r.Err != nil {
+		return r.Err
+	}
+
+	attr.Data = body
+
+	return nil
+}
+
+// Write validate and write attribute
+func (attr *Attribute) Write(writer io.Writer) error {
+	if err := attr.Validate(); err != nil {
+		return err
+	}
+
+	w := NewBinWriter(writer)
+
+	w.WriteU8(attr.Usage)
+
+	switch {
+	case attr.Usage == ECDH02 || attr.Usage == ECDH03:
+		// the usage is the public key prefix
+		w.WriteBytes(attr.Data[1:])
+	case attr.Usage == DescriptionURL:
+		w.WriteU8(byte(len(attr.Data)))
+		w.WriteBytes(attr.Data)
+	case attr.Usage == Description || isRemarkUsage(attr.Usage):
+		w.WriteVarBytes(attr.Data)
+	default:
+		w.WriteBytes(attr.Data)
+	}
+
+	return w.Err
+}
@@ -0,0 +1,367 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Protocol limits of neo 2.x used when decoding untrusted data
+const (
+	MaxTransactionAttributes = 16
+	MaxTransactionInputs     = 0x1000000
+	MaxTransactionOutputs    = math.MaxUint16 + 1
+	MaxTransactionWitnesses  = 0x1000000
+	MaxWitnessScriptSize     = 65536
+	MaxVarBytesSize          = 0x1000000
+)
+
+// Decode errors
+var (
+	ErrNonCanonicalVarint = errors.New("non-canonical varint encoding")
+	ErrLimitExceeded      = errors.New("length exceeds protocol limit")
+)
+
+// DecodeError decoding error with the byte offset and the field being decoded
+type DecodeError struct {
+	Offset int64  // offset of the field in the decoded stream
+	Field  string // field name
+	Err    error  // underlying error
+}
+
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s at offset %d: %s", err.Field, err.Offset, err.Err)
+}
+
+// Unwrap get the underlying error
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// BinReader little endian binary reader with sticky error, every read is a full read,
+// after the first error all reads are no-op and return zero values
+type BinReader struct {
+	reader io.Reader
+	Offset int64 // consumed bytes
+	Err    error // first error, always *DecodeError
+}
+
+// NewBinReader create binary reader, the reader is returned as is if it is already a *BinReader,
+// so nested Read(io.Reader) calls share the offset and error
+func NewBinReader(reader io.Reader) *BinReader {
+	if binReader, ok := reader.(*BinReader); ok {
+		return binReader
+	}
+
+	return &BinReader{
+		reader: reader,
+	}
+}
+
+// Read implement io.Reader
+func (r *BinReader) Read(p []byte) (int, error) {
+	if r.Err != nil {
+		return 0, r.Err
+	}
+
+	n, err := r.reader.Read(p)
+
+	r.Offset += int64(n)
+
+	return n, err
+}
+
+// Fail record error of field, the first error is kept
+func (r *BinReader) Fail(field string, err error) {
+	if r.Err != nil {
+		return
+	}
+
+	if decodeErr, ok := err.(*DecodeError); ok {
+		r.Err = decodeErr
+		return
+	}
+
+	r.Err = &DecodeError{
+		Offset: r.Offset,
+		Field:  field,
+		Err:    err,
+	}
+}
+
+// ReadBytes read exactly n bytes, large buffers grow with the received data
+// so a forged length can't allocate more memory than the input size
+func (r *BinReader) ReadBytes(field string, n int) []byte {
+	if r.Err != nil {
+		return nil
+	}
+
+	offset := r.Offset
+
+	var data []byte
+	var err error
+
+	if n <= 4096 {
+		data = make([]byte, n)
+
+		_, err = io.ReadFull(r, data)
+	} else {
+		var buff bytes.Buffer
+
+		_, err = io.CopyN(&buff, r, int64(n))
+
+		data = buff.Bytes()
+	}
+
+	if err != nil {
+		if err == io.EOF && n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+
+		r.failAt(offset, field, err)
+
+		return nil
+	}
+
+	return data
+}
+
+// ReadU8 read one byte
+func (r *BinReader) ReadU8(field string) byte {
+	data := r.ReadBytes(field, 1)
+
+	if data == nil {
+		return 0
+	}
+
+	return data[0]
+}
+
+// ReadUint16 read little endian uint16
+func (r *BinReader) ReadUint16(field string) uint16 {
+	data := r.ReadBytes(field, 2)
+
+	if data == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint16(data)
+}
+
+// ReadUint32 read little endian uint32
+func (r *BinReader) ReadUint32(field string) uint32 {
+	data := r.ReadBytes(field, 4)
+
+	if data == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint32(data)
+}
+
+// ReadUint64 read little endian uint64
+func (r *BinReader) ReadUint64(field string) uint64 {
+	data := r.ReadBytes(field, 8)
+
+	if data == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(data)
+}
+
+// ReadVarUint read canonical varint which is not greater than max
+func (r *BinReader) ReadVarUint(field string, max uint64) uint64 {
+	offset := r.Offset
+
+	flag := r.ReadU8(field)
+
+	var value, min uint64
+
+	switch flag {
+	case 0xFD:
+		value, min = uint64(r.ReadUint16(field)), 0xFD
+	case 0xFE:
+		value, min = uint64(r.ReadUint32(field)), math.MaxUint16+1
+	case 0xFF:
+		value, min = r.ReadUint64(field), math.MaxUint32+1
+	default:
+		value = uint64(flag)
+	}
+
+	if r.Err != nil {
+		return 0
+	}
+
+	if value < min {
+		r.failAt(offset, field, ErrNonCanonicalVarint)
+		return 0
+	}
+
+	if value > max {
+		r.failAt(offset, field, fmt.Errorf("%w: %d > %d", ErrLimitExceeded, value, max))
+		return 0
+	}
+
+	return value
+}
+
+func (r *BinReader) failAt(offset int64, field string, err error) {
+	current := r.Offset
+
+	r.Offset = offset
+	r.Fail(field, err)
+	r.Offset = current
+}
+
+// ReadVarBytes read varint length prefixed bytes
+func (r *BinReader) ReadVarBytes(field string, max int) []byte {
+	length := r.ReadVarUint(field, uint64(max))
+
+	data := r.ReadBytes(field, int(length))
+
+	if data == nil && r.Err == nil {
+		return []byte{}
+	}
+
+	return data
+}
+
+// ReadVarString read varint length prefixed string
+func (r *BinReader) ReadVarString(field string, max int) string {
+	return string(r.ReadVarBytes(field, max))
+}
+
+// ReadSerializable read nested serializable object
+func (r *BinReader) ReadSerializable(field string, value Serializable) {
+	if r.Err != nil {
+		return
+	}
+
+	offset := r.Offset
+
+	if err := value.Read(r); err != nil {
+		if decodeErr, ok := err.(*DecodeError); ok && decodeErr == r.Err {
+			decodeErr.Field = field + "." + decodeErr.Field
+			return
+		}
+
+		r.failAt(offset, field, err)
+	}
+}
+
+// BinWriter little endian binary writer with sticky error
+type BinWriter struct {
+	writer io.Writer
+	Err    error // first error
+}
+
+// NewBinWriter create binary writer, the writer is returned as is if it is already a *BinWriter
+func NewBinWriter(writer io.Writer) *BinWriter {
+	if binWriter, ok := writer.(*BinWriter); ok {
+		return binWriter
+	}
+
+	return &BinWriter{
+		writer: writer,
+	}
+}
+
+// Write implement io.Writer
+func (w *BinWriter) Write(p []byte) (int, error) {
+	if w.Err != nil {
+		return 0, w.Err
+	}
+
+	n, err := w.writer.Write(p)
+
+	w.Err = err
+
+	return n, err
+}
+
+// Fail record error, the first error is kept
+func (w *BinWriter) Fail(err error) {
+	if w.Err == nil {
+		w.Err = err
+	}
+}
+
+// WriteBytes write raw bytes
+func (w *BinWriter) WriteBytes(data []byte) {
+	w.Write(data)
+}
+
+// WriteU8 write one byte
+func (w *BinWriter) WriteU8(value byte) {
+	w.Write([]byte{value})
+}
+
+// WriteUint16 write little endian uint16
+func (w *BinWriter) WriteUint16(value uint16) {
+	data := make([]byte, 2)
+
+	binary.LittleEndian.PutUint16(data, value)
+
+	w.Write(data)
+}
+
+// WriteUint32 write little endian uint32
+func (w *BinWriter) WriteUint32(value uint32) {
+	data := make([]byte, 4)
+
+	binary.LittleEndian.PutUint32(data, value)
+
+	w.Write(data)
+}
+
+// WriteUint64 write little endian uint64
+func (w *BinWriter) WriteUint64(value uint64) {
+	data := make([]byte, 8)
+
+	binary.LittleEndian.PutUint64(data, value)
+
+	w.Write(data)
+}
+
+// WriteVarUint write canonical varint
+func (w *BinWriter) WriteVarUint(value uint64) {
+	switch {
+	case value < 0xFD:
+		w.WriteU8(byte(value))
+	case value <= math.MaxUint16:
+		w.WriteU8(0xFD)
+		w.WriteUint16(uint16(value))
+	case value <= math.MaxUint32:
+		w.WriteU8(0xFE)
+		w.WriteUint32(uint32(value))
+	default:
+		w.WriteU8(0xFF)
+		w.WriteUint64(value)
+	}
+}
+
+// WriteVarBytes write varint length prefixed bytes
+func (w *BinWriter) WriteVarBytes(data []byte) {
+	w.WriteVarUint(uint64(len(data)))
+	w.WriteBytes(data)
+}
+
+// WriteVarString write varint length prefixed string
+func (w *BinWriter) WriteVarString(data string) {
+	w.WriteVarBytes([]byte(data))
+}
+
+// WriteSerializable write nested serializable object
+func (w *BinWriter) WriteSerializable(value Serializable) {
+	if w.Err != nil {
+		return
+	}
+
+	if err := value.Write(w); err != nil {
+		w.Fail(err)
+	}
+}
@@ -0,0 +1,378 @@
+package tx
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/inwecrypto/neogo/rpc"
+)
+
+// Builder errors
+var (
+	ErrBuilderNoSender   = errors.New("builder requires From addresses")
+	ErrBuilderNoPayload  = errors.New("builder has nothing to build")
+	ErrBuilderConflict   = errors.New("claim can't be combined with payments or invocation")
+	ErrBuilderFeeOnClaim = errors.New("claim transaction network fee is not supported")
+)
+
+// Builder fluent transaction builder of contract, invocation and claim transactions,
+// the first error is kept and returned by Build, the built transaction is unsigned
+type Builder struct {
+	err        error
+	from       []string
+	outputs    []*Vout
+	attributes []*Attribute
+	remarks    int
+	script     []byte
+	gas        Fixed8
+	claimTo    string
+	claims     []*ClaimReference
+	fee        bool
+	options    []CalcOption
+}
+
+// From create builder spending utxos of addresses, the first address pays the invocation witness
+// when the transaction has no input
+func From(addresses ...string) *Builder {
+	builder := &Builder{}
+
+	if len(addresses) == 0 {
+		builder.err = ErrBuilderNoSender
+
+		return builder
+	}
+
+	for _, address := range addresses {
+		if _, err := decodeAddress(address); err != nil {
+			builder.err = fmt.Errorf("invalid from address %s: %s", address, err)
+
+			return builder
+		}
+	}
+
+	builder.from = addresses
+
+	return builder
+}
+
+// Err get the first error
+func (builder *Builder) Err() error {
+	return builder.err
+}
+
+// Pay add output paying amount of asset to address
+func (builder *Builder) Pay(asset string, to string, amount Fixed8) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if _, err := decodeHash(asset); err != nil {
+		builder.err = fmt.Errorf("invalid asset %s: %s", asset, err)
+
+		return builder
+	}
+
+	if _, err := decodeAddress(to); err != nil {
+		builder.err = fmt.Errorf("invalid pay address %s: %s", to, err)
+
+		return builder
+	}
+
+	if amount <= 0 {
+		builder.err = fmt.Errorf("invalid pay amount %s", amount)
+
+		return builder
+	}
+
+	builder.outputs = append(builder.outputs, &Vout{
+		Asset:   asset,
+		Value:   amount,
+		Address: to,
+	})
+
+	return builder
+}
+
+// Remark add remark attribute, the usages Remark to Remark15 are taken in order
+func (builder *Builder) Remark(data []byte) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	attr, err := NewRemarkAttribute(builder.remarks, data)
+
+	if err != nil {
+		builder.err = err
+
+		return builder
+	}
+
+	builder.remarks++
+
+	return builder.Attribute(attr)
+}
+
+// Attribute add attribute
+func (builder *Builder) Attribute(attr *Attribute) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if err := attr.Validate(); err != nil {
+		builder.err = err
+
+		return builder
+	}
+
+	if len(builder.attributes) >= MaxTransactionAttributes {
+		builder.err = fmt.Errorf("%w: too many attributes", ErrLimitExceeded)
+
+		return builder
+	}
+
+	builder.attributes = append(builder.attributes, attr)
+
+	return builder
+}
+
+// NetworkFee pay fixed network fee
+func (builder *Builder) NetworkFee(fee Fixed8) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if fee < 0 {
+		builder.err = fmt.Errorf("invalid network fee %s", fee)
+
+		return builder
+	}
+
+	builder.fee = true
+
+	return builder.Options(WithNetworkFee(fee))
+}
+
+// FeePolicy pay network fee required by policy, see WithFeePolicy
+func (builder *Builder) FeePolicy(policy *FeePolicy, witnessSizes ...int) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if policy == nil {
+		builder.err = errors.New("nil fee policy")
+
+		return builder
+	}
+
+	builder.fee = true
+
+	return builder.Options(WithFeePolicy(policy, witnessSizes...))
+}
+
+// ChangeTo send all change to address
+func (builder *Builder) ChangeTo(address string) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if _, err := decodeAddress(address); err != nil {
+		builder.err = fmt.Errorf("invalid change address %s: %s", address, err)
+
+		return builder
+	}
+
+	return builder.Options(WithChange(ChangeToAddress(address)))
+}
+
+// Options add CalcInputs options, e.g. WithSelector or WithMaxInputs
+func (builder *Builder) Options(options ...CalcOption) *Builder {
+	if builder.err == nil {
+		builder.options = append(builder.options, options...)
+	}
+
+	return builder
+}
+
+// Invoke build invocation transaction running script with gas as system fee
+func (builder *Builder) Invoke(script []byte, gas Fixed8) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if len(script) == 0 {
+		builder.err = errors.New("empty invocation script")
+
+		return builder
+	}
+
+	if gas < 0 {
+		builder.err = fmt.Errorf("invalid invocation gas %s", gas)
+
+		return builder
+	}
+
+	builder.script = script
+	builder.gas = gas
+
+	return builder
+}
+
+// Claim build claim transaction of references, the claimed GAS is sent to address,
+// references without claimable GAS are skipped, use BuildClaimTxs to split large claims
+func (builder *Builder) Claim(to string, references ...*ClaimReference) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if _, err := decodeAddress(to); err != nil {
+		builder.err = fmt.Errorf("invalid claim address %s: %s", to, err)
+
+		return builder
+	}
+
+	// references without claimable GAS are skipped
+	for _, reference := range references {
+		if reference.Gas > 0 {
+			builder.claims = append(builder.claims, reference)
+		}
+	}
+
+	if len(builder.claims) == 0 {
+		builder.err = ErrNoUTXO
+
+		return builder
+	}
+
+	builder.claimTo = to
+
+	return builder
+}
+
+// Build build unsigned transaction, the inputs are selected from the unspent utxos of From addresses
+func (builder *Builder) Build(unspent []*rpc.UTXO) (*Transaction, error) {
+	if builder.err != nil {
+		return nil, builder.err
+	}
+
+	if len(builder.claims) > 0 {
+		return builder.buildClaim()
+	}
+
+	var owned []*rpc.UTXO
+
+	for _, utxo := range unspent {
+		for _, address := range builder.from {
+			if utxo.Vout.Address == address {
+				owned = append(owned, utxo)
+
+				break
+			}
+		}
+	}
+
+	if builder.script != nil {
+		return builder.buildInvocation(owned)
+	}
+
+	if len(builder.outputs) == 0 {
+		return nil, ErrBuilderNoPayload
+	}
+
+	tx := NewContractTx()
+
+	tx.Attributes = builder.copyAttributes()
+
+	if err := tx.CalcInputs(builder.copyOutputs(), owned, builder.options...); err != nil {
+		return nil, err
+	}
+
+	return tx.Tx(), nil
+}
+
+func (builder *Builder) buildInvocation(unspent []*rpc.UTXO) (*Transaction, error) {
+	tx := &InvocationTx{
+		Type:       InvocationTransaction,
+		Version:    1,
+		Attributes: builder.copyAttributes(),
+		Extend: &invocationTx{
+			Script: builder.script,
+			Gas:    builder.gas,
+		},
+	}
+
+	if builder.gas > 0 || builder.fee || len(builder.outputs) > 0 {
+		if err := tx.CalcInputs(builder.copyOutputs(), unspent, builder.options...); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tx.Inputs) > 0 {
+		return tx.Tx(), nil
+	}
+
+	// free invocation without input, witness the sender and make the txid unique
+	scriptHash, _ := decodeAddress(builder.from[0])
+
+	attr, err := NewScriptAttribute(scriptHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 8)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	remark, err := NewRemarkAttribute(15, nonce)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx.Attributes = append(tx.Attributes, attr, remark)
+
+	if len(tx.Attributes) > MaxTransactionAttributes {
+		return nil, fmt.Errorf("%w: too many attributes", ErrLimitExceeded)
+	}
+
+	return tx.Tx(), nil
+}
+
+func (builder *Builder) buildClaim() (*Transaction, error) {
+	if len(builder.outputs) > 0 || builder.script != nil {
+		return nil, ErrBuilderConflict
+	}
+
+	if builder.fee {
+		return nil, ErrBuilderFeeOnClaim
+	}
+
+	tx, err := newClaimTx(builder.claimTo, builder.claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx.Attributes = builder.copyAttributes()
+
+	return tx.Tx(), nil
+}
+
+// copyAttributes every built transaction gets its own slice, so a builder can build several transactions
+func (builder *Builder) copyAttributes() []*Attribute {
+	return append([]*Attribute(nil), builder.attributes...)
+}
+
+func (builder *Builder) copyOutputs() []*Vout {
+	outputs := make([]*Vout, len(builder.outputs))
+
+	for i, vout := range builder.outputs {
+		output := *vout
+		outputs[i] = &output
+	}
+
+	return outputs
+}
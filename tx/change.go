@@ -0,0 +1,120 @@
+package tx
+
+import (
+	"errors"
+
+	"github.com/inwecrypto/neogo/rpc"
+)
+
+// ChangePolicy decide which address receives the change output of one asset,
+// selected is the utxos spent for that asset
+type ChangePolicy interface {
+	ChangeAddress(asset string, selected []*rpc.UTXO) (string, error)
+}
+
+// ChangePolicyFunc function adapter of ChangePolicy
+type ChangePolicyFunc func(asset string, selected []*rpc.UTXO) (string, error)
+
+// ChangeAddress implement ChangePolicy
+func (f ChangePolicyFunc) ChangeAddress(asset string, selected []*rpc.UTXO) (string, error) {
+	return f(asset, selected)
+}
+
+// Builtin change policies
+var (
+	// ChangeToFirstInput send change back to the owner of the first selected utxo, the historical behavior
+	ChangeToFirstInput ChangePolicy = ChangePolicyFunc(changeToFirstInput)
+	// ChangeToLargestContributor send change back to the address which contributes the most value of the asset
+	ChangeToLargestContributor ChangePolicy = ChangePolicyFunc(changeToLargestContributor)
+)
+
+// ChangeToAddress send all change to one fixed address
+func ChangeToAddress(address string) ChangePolicy {
+	return ChangePolicyFunc(func(asset string, selected []*rpc.UTXO) (string, error) {
+		if _, err := decodeAddress(address); err != nil {
+			return "", err
+		}
+
+		return address, nil
+	})
+}
+
+// ChangeToNewAddress send change to the address returned by next, e.g. a freshly derived HD wallet address
+func ChangeToNewAddress(next func() (string, error)) ChangePolicy {
+	return ChangePolicyFunc(func(asset string, selected []*rpc.UTXO) (string, error) {
+		address, err := next()
+
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := decodeAddress(address); err != nil {
+			return "", err
+		}
+
+		return address, nil
+	})
+}
+
+func changeToFirstInput(asset string, selected []*rpc.UTXO) (string, error) {
+	if len(selected) == 0 {
+		return "", errors.New("no selected utxo to receive change")
+	}
+
+	return selected[0].Vout.Address, nil
+}
+
+func changeToLargestContributor(asset string, selected []*rpc.UTXO) (string, error) {
+	if len(selected) == 0 {
+		return "", errors.New("no selected utxo to receive change")
+	}
+
+	var addresses []string
+
+	contribution := make(map[string]Fixed8)
+
+	for _, utxo := range selected {
+		if _, ok := contribution[utxo.Vout.Address]; !ok {
+			addresses = append(addresses, utxo.Vout.Address)
+		}
+
+		contribution[utxo.Vout.Address] += utxo.Value()
+	}
+
+	largest := addresses[0]
+
+	for _, address := range addresses[1:] {
+		if contribution[address] > contribution[largest] {
+			largest = address
+		}
+	}
+
+	return largest, nil
+}
+
+// WithChange set change policy, default is ChangeToFirstInput
+func WithChange(policy ChangePolicy) CalcOption {
+	return func(options *calcOptions) {
+		options.change = policy
+	}
+}
+
+// InputAddresses get the distinct owner addresses of the inputs in input order,
+// only inputs filled by CalcInputs carry owner address
+func (tx *Transaction) InputAddresses() []string {
+	var addresses []string
+
+	seen := make(map[string]bool)
+
+	for _, vin := range tx.Inputs {
+		if vin.Address == "" || seen[vin.Address] {
+			continue
+		}
+
+		seen[vin.Address] = true
+
+		addresses = append(addresses, vin.Address)
+	}
+
+	return addresses
+}
@@ -2,16 +2,228 @@ package tx
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"math"
+	"strings"
 
 	"github.com/inwecrypto/neogo/rpc"
 )
 
+// DefaultClaimLimit max claim references of one claim transaction, keeps single signature claim
+// transaction under the 1024 bytes free size
+const DefaultClaimLimit = 25
+
+// GAS generation parameters of neo 2.x
+const decrementInterval = 2000000
+
+var generationAmount = []uint64{8, 7, 6, 5, 4, 3, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+
+// CalculateBonus calculate GAS claimable by value NEO which is held from start height to end height,
+// sysFeeAmount is the total system fee in whole GAS of the blocks in [start, end)
+func CalculateBonus(value Fixed8, start, end uint32, sysFeeAmount int64) (Fixed8, error) {
+	if end <= start {
+		return 0, fmt.Errorf("invalid claim height range [%d, %d)", start, end)
+	}
+
+	amount := uint64(0)
+
+	ustart := start / decrementInterval
+
+	if int(ustart) < len(generationAmount) {
+		istart := start % decrementInterval
+		uend := end / decrementInterval
+		iend := end % decrementInterval
+
+		if int(uend) >= len(generationAmount) {
+			uend = uint32(len(generationAmount))
+			iend = 0
+		}
+
+		if iend == 0 {
+			uend--
+			iend = decrementInterval
+		}
+
+		for ustart < uend {
+			amount += uint64(decrementInterval-istart) * generationAmount[ustart]
+			ustart++
+			istart = 0
+		}
+
+		amount += uint64(iend-istart) * generationAmount[ustart]
+	}
+
+	if sysFeeAmount < 0 {
+		return 0, fmt.Errorf("invalid system fee amount %d", sysFeeAmount)
+	}
+
+	amount += uint64(sysFeeAmount)
+
+	// neo calculates value / 100000000 * amount with Fixed8 integer arithmetic
+	neo := int64(value) / 100000000
+
+	if amount > math.MaxInt64 || (neo != 0 && int64(amount) > math.MaxInt64/neo) {
+		return 0, rpc.ErrFixed8Overflow
+	}
+
+	return Fixed8(neo * int64(amount)), nil
+}
+
+// ClaimReference spent NEO output which can claim GAS
+type ClaimReference struct {
+	TxID        string // txid of the NEO output
+	N           uint16 // output index
+	Address     string // output owner
+	Value       Fixed8 // NEO amount
+	StartHeight uint32 // height of the block creating the output
+	EndHeight   uint32 // height of the block spending the output
+	Gas         Fixed8 // claimable GAS
+}
+
+// ClaimReferences convert the unclaimed result of the extend rpc api
+func ClaimReferences(unclaimed *rpc.Unclaimed) ([]*ClaimReference, error) {
+	var references []*ClaimReference
+
+	for _, utxo := range unclaimed.Claims {
+		if utxo.Vout.Asset != NEOAssert {
+			return nil, fmt.Errorf("claim reference %s:%d is not NEO output", utxo.TransactionID, utxo.Vout.N)
+		}
+
+		gas, err := ParseFixed8(utxo.Gas)
+
+		if err != nil {
+			return nil, err
+		}
+
+		references = append(references, &ClaimReference{
+			TxID:        utxo.TransactionID,
+			N:           uint16(utxo.Vout.N),
+			Address:     utxo.Vout.Address,
+			Value:       utxo.Value(),
+			StartHeight: uint32(utxo.Block),
+			EndHeight:   uint32(utxo.SpentBlock),
+			Gas:         gas,
+		})
+	}
+
+	return references, nil
+}
+
+// CalculateGas calculate the reference's claimable GAS, see CalculateBonus
+func (reference *ClaimReference) CalculateGas(sysFeeAmount int64) (err error) {
+	reference.Gas, err = CalculateBonus(reference.Value, reference.StartHeight, reference.EndHeight, sysFeeAmount)
+
+	return
+}
+
+// BuildClaimTxs build claim transactions paying all claimable GAS of references to address,
+// duplicated references and references without claimable GAS are removed and the rest are split by limit (DefaultClaimLimit if limit <= 0),
+// the transactions are signed when signers are given
+func BuildClaimTxs(to string, references []*ClaimReference, limit int, signers ...Signer) ([]*ClaimTx, error) {
+	if _, err := decodeAddress(to); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultClaimLimit
+	}
+
+	var unique []*ClaimReference
+
+	claimed := make(map[string]bool)
+
+	for _, reference := range references {
+		key := fmt.Sprintf("%s:%d", strings.ToLower(strings.TrimPrefix(reference.TxID, "0x")), reference.N)
+
+		if claimed[key] {
+			continue
+		}
+
+		claimed[key] = true
+
+		// nothing to claim
+		if reference.Gas <= 0 {
+			continue
+		}
+
+		unique = append(unique, reference)
+	}
+
+	if len(unique) == 0 {
+		return nil, ErrNoUTXO
+	}
+
+	var txs []*ClaimTx
+
+	for start := 0; start < len(unique); start += limit {
+		end := start + limit
+
+		if end > len(unique) {
+			end = len(unique)
+		}
+
+		tx, err := newClaimTx(to, unique[start:end])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(signers) > 0 {
+			if err := tx.Tx().SignWith(signers...); err != nil {
+				return nil, err
+			}
+
+			if _, err := tx.Tx().RawTx(); err != nil {
+				return nil, err
+			}
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
+
+func newClaimTx(to string, references []*ClaimReference) (*ClaimTx, error) {
+	amount := Fixed8(0)
+
+	claims := &claimTx{}
+
+	for _, reference := range references {
+		var err error
+
+		if amount, err = amount.Add(reference.Gas); err != nil {
+			return nil, err
+		}
+
+		claims.Inputs = append(claims.Inputs, &Vin{
+			Tx:      hashString(reference.TxID),
+			N:       reference.N,
+			Address: reference.Address,
+		})
+	}
+
+	tx := NewClaimTx()
+
+	tx.Extend = claims
+
+	tx.Outputs = []*Vout{
+		&Vout{
+			Asset:   GasAssert,
+			Value:   amount,
+			Address: to,
+		},
+	}
+
+	return tx, nil
+}
+
 // ClaimTx .
 type ClaimTx Transaction
 
 type claimTx struct {
-	Inputs []*Vin `json:"inputs"`
+	Inputs []*Vin `json:"claims"`
 }
 
 // NewClaimTx .
@@ -37,7 +249,7 @@ func (tx *ClaimTx) Tx() *Transaction {
 }
 
 // Claim .
-func (tx *ClaimTx) Claim(amount float64, to string, claims []*rpc.UTXO) error {
+func (tx *ClaimTx) Claim(amount Fixed8, to string, claims []*rpc.UTXO) error {
 
 	var inputs []*Vin
 
@@ -47,8 +259,9 @@ func (tx *ClaimTx) Claim(amount float64, to string, claims []*rpc.UTXO) error {
 		}
 
 		inputs = append(inputs, &Vin{
-			Tx: utxo.TransactionID,
-			N:  uint16(utxo.Vout.N),
+			Tx:      utxo.TransactionID,
+			N:       uint16(utxo.Vout.N),
+			Address: utxo.Vout.Address,
 		})
 	}
 
@@ -63,7 +276,7 @@ func (tx *ClaimTx) Claim(amount float64, to string, claims []*rpc.UTXO) error {
 	tx.Outputs = []*Vout{
 		&Vout{
 			Asset:   GasAssert,
-			Value:   MakeFixed8(amount),
+			Value:   amount,
 			Address: to,
 		},
 	}
@@ -72,38 +285,51 @@ func (tx *ClaimTx) Claim(amount float64, to string, claims []*rpc.UTXO) error {
 }
 
 func (tx *claimTx) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
 
-	length := Varint(len(tx.Inputs))
-
-	if err := length.Write(writer); err != nil {
-		return err
-	}
+	w.WriteVarUint(uint64(len(tx.Inputs)))
 
 	for _, vin := range tx.Inputs {
-		if err := (*Vin)(vin).Write(writer); err != nil {
-			return err
-		}
+		w.WriteSerializable(vin)
 	}
 
-	return nil
+	return w.Err
 }
 
 func (tx *claimTx) Read(reader io.Reader) error {
-	var length Varint
+	r := NewBinReader(reader)
+
+	offset := r.Offset
+
+	length := r.ReadVarUint("claims", MaxTransactionInputs)
 
-	if err := length.Read(reader); err != nil {
-		return err
+	if r.Err == nil && length == 0 {
+		r.failAt(offset, "claims", fmt.Errorf("empty claims"))
 	}
 
-	for i := 0; i < int(length); i++ {
-		var vin Vin
+	for i := uint64(0); i < length && r.Err == nil; i++ {
+		vin := &Vin{}
+
+		r.ReadSerializable(fmt.Sprintf("claims[%d]", i), vin)
+
+		tx.Inputs = append(tx.Inputs, vin)
+	}
 
-		if err := vin.Read(reader); err != nil {
-			return err
+	return r.Err
+}
+
+func (tx *claimTx) scriptHashesForVerifying(resolver OutputResolver) ([][]byte, error) {
+	var hashes [][]byte
+
+	for _, vin := range tx.Inputs {
+		owner, err := inputOwner(vin, resolver)
+
+		if err != nil {
+			return nil, err
 		}
 
-		tx.Inputs = append(tx.Inputs, &vin)
+		hashes = append(hashes, owner)
 	}
 
-	return nil
+	return hashes, nil
 }
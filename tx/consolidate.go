@@ -0,0 +1,287 @@
+package tx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/inwecrypto/neogo/rpc"
+)
+
+type consolidateOptions struct {
+	maxInputs   int
+	maxSize     int
+	feePolicy   *FeePolicy
+	feeBudget   Fixed8
+	witnessSize int
+	assets      []string
+	sweepAll    bool
+}
+
+// ConsolidateOption Consolidate option
+type ConsolidateOption func(options *consolidateOptions)
+
+// WithConsolidateMaxInputs limit the input count of every consolidation transaction
+func WithConsolidateMaxInputs(maxInputs int) ConsolidateOption {
+	return func(options *consolidateOptions) {
+		options.maxInputs = maxInputs
+	}
+}
+
+// WithConsolidateMaxSize limit the estimated signed size of every consolidation transaction,
+// default is the free size of the fee policy, or of DefaultFeePolicy when no policy is set
+func WithConsolidateMaxSize(maxSize int) ConsolidateOption {
+	return func(options *consolidateOptions) {
+		options.maxSize = maxSize
+	}
+}
+
+// WithConsolidateFeePolicy pay network fee required by policy, the fee is deducted from the merged GAS output,
+// transactions of other assets or of GAS too small to pay fee are limited to the free size of the policy
+func WithConsolidateFeePolicy(policy *FeePolicy) ConsolidateOption {
+	return func(options *consolidateOptions) {
+		options.feePolicy = policy
+	}
+}
+
+// WithFeeBudget limit the total network fee of all consolidation transactions,
+// transactions are made smaller to fit the rest of the budget
+func WithFeeBudget(budget Fixed8) ConsolidateOption {
+	return func(options *consolidateOptions) {
+		options.feeBudget = budget
+	}
+}
+
+// WithConsolidateWitnessSize set the estimated witness size of every input owner,
+// default is SingleSigWitnessSize, see MultiSigWitnessSize
+func WithConsolidateWitnessSize(witnessSize int) ConsolidateOption {
+	return func(options *consolidateOptions) {
+		options.witnessSize = witnessSize
+	}
+}
+
+// WithAssets merge utxos of assets, default is NEO and GAS
+func WithAssets(assets ...string) ConsolidateOption {
+	return func(options *consolidateOptions) {
+		options.assets = assets
+	}
+}
+
+// WithSweepAll merge utxos of all assets, single utxos are moved too, e.g. to empty an address
+func WithSweepAll() ConsolidateOption {
+	return func(options *consolidateOptions) {
+		options.sweepAll = true
+	}
+}
+
+func newConsolidateOptions(options []ConsolidateOption) *consolidateOptions {
+	result := &consolidateOptions{
+		feeBudget:   -1,
+		witnessSize: SingleSigWitnessSize,
+		assets:      []string{NEOAssert, GasAssert},
+	}
+
+	for _, option := range options {
+		option(result)
+	}
+
+	if result.maxSize <= 0 {
+		result.maxSize = DefaultFeePolicy.FreeSize
+
+		if result.feePolicy != nil {
+			result.maxSize = result.feePolicy.FreeSize
+		}
+	}
+
+	return result
+}
+
+// Consolidate merge unspent utxos into one output of the destination address per asset,
+// the utxos are split into as many ContractTx as needed to stay under the input count, size and fee limits.
+// Small utxos are merged first, a utxo already owned by the destination is not moved alone unless sweeping
+func Consolidate(to string, unspent []*rpc.UTXO, options ...ConsolidateOption) ([]*ContractTx, error) {
+	if _, err := decodeAddress(to); err != nil {
+		return nil, err
+	}
+
+	consolidateOptions := newConsolidateOptions(options)
+
+	groups := make(map[string][]*rpc.UTXO)
+
+	spent := make(map[string]bool)
+
+	for _, utxo := range unspent {
+		key := fmt.Sprintf("%s:%d", strings.ToLower(strings.TrimPrefix(utxo.TransactionID, "0x")), utxo.Vout.N)
+
+		if spent[key] {
+			continue
+		}
+
+		spent[key] = true
+
+		groups[utxo.Vout.Asset] = append(groups[utxo.Vout.Asset], utxo)
+	}
+
+	assets := consolidateOptions.assets
+
+	if consolidateOptions.sweepAll {
+		assets = consolidateAssets(groups)
+	}
+
+	var txs []*ContractTx
+
+	found := false
+
+	budget := consolidateOptions.feeBudget
+
+	for _, asset := range assets {
+		candidates := sortUTXO(groups[asset], false)
+
+		if len(candidates) > 0 {
+			found = true
+		}
+
+		for len(candidates) > 0 {
+			tx, count, fee, err := consolidateChunk(to, asset, candidates, budget, consolidateOptions)
+
+			if err != nil {
+				return nil, err
+			}
+
+			candidates = candidates[count:]
+
+			if tx == nil {
+				continue
+			}
+
+			if budget >= 0 {
+				budget -= fee
+			}
+
+			txs = append(txs, tx)
+		}
+	}
+
+	if !found {
+		return nil, ErrNoUTXO
+	}
+
+	return txs, nil
+}
+
+// consolidateAssets NEO and GAS first, then other assets in id order
+func consolidateAssets(groups map[string][]*rpc.UTXO) []string {
+	var assets []string
+
+	for asset := range groups {
+		if asset != NEOAssert && asset != GasAssert {
+			assets = append(assets, asset)
+		}
+	}
+
+	sort.Strings(assets)
+
+	return append([]string{NEOAssert, GasAssert}, assets...)
+}
+
+// consolidateChunk merge the leading candidates which fit the limits, return the transaction,
+// the count of consumed candidates and the network fee, the transaction is nil if there is nothing to move
+func consolidateChunk(to string, asset string, candidates []*rpc.UTXO, budget Fixed8, options *consolidateOptions) (*ContractTx, int, Fixed8, error) {
+	maxSize := options.maxSize
+
+	if options.feePolicy != nil && asset != GasAssert && options.feePolicy.FreeSize < maxSize {
+		maxSize = options.feePolicy.FreeSize
+	}
+
+	tx := NewContractTx()
+
+	tx.Outputs = []*Vout{
+		&Vout{
+			Asset:   asset,
+			Address: to,
+		},
+	}
+
+	owners := make(map[string]bool)
+
+	var witnessSizes []int
+
+	value := Fixed8(0)
+	fee := Fixed8(0)
+
+	count := 0
+
+	for _, utxo := range candidates {
+		if options.maxInputs > 0 && count == options.maxInputs {
+			break
+		}
+
+		tx.Inputs = append(tx.Inputs, &Vin{
+			Tx:      utxo.TransactionID,
+			N:       uint16(utxo.Vout.N),
+			Address: utxo.Vout.Address,
+		})
+
+		newOwner := !owners[utxo.Vout.Address]
+
+		if newOwner {
+			witnessSizes = append(witnessSizes, options.witnessSize)
+		}
+
+		size := tx.Tx().Size(witnessSizes...)
+
+		requiredFee := Fixed8(0)
+
+		if options.feePolicy != nil {
+			var err error
+
+			if requiredFee, err = options.feePolicy.Fee(size); err != nil {
+				return nil, 0, 0, err
+			}
+		}
+
+		if size > maxSize || (budget >= 0 && requiredFee > budget) {
+			tx.Inputs = tx.Inputs[:count]
+
+			break
+		}
+
+		owners[utxo.Vout.Address] = true
+
+		var err error
+
+		if value, err = value.Add(utxo.Value()); err != nil {
+			return nil, 0, 0, err
+		}
+
+		fee = requiredFee
+
+		count++
+	}
+
+	if count == 0 {
+		return nil, 0, 0, ErrInputLimit
+	}
+
+	// nothing to merge
+	if count == 1 && !options.sweepAll && candidates[0].Vout.Address == to {
+		return nil, 1, 0, nil
+	}
+
+	if fee > 0 {
+		// dust can't pay the fee, merge as many utxos as fit the free size instead
+		if value <= fee {
+			free := *options
+
+			free.maxSize = options.feePolicy.FreeSize
+
+			return consolidateChunk(to, asset, candidates, budget, &free)
+		}
+
+		value -= fee
+	}
+
+	tx.Outputs[0].Value = value
+
+	return tx, count, fee, nil
+}
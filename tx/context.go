@@ -0,0 +1,436 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/inwecrypto/neogo/ecc"
+	"github.com/inwecrypto/neogo/script"
+)
+
+// contextTypePrefix neo-gui context type is the full name of the transaction class
+const contextTypePrefix = "Neo.Network.P2P.Payloads."
+
+// ContextItem signing state of one script hash required by the transaction
+type ContextItem struct {
+	ScriptHash []byte            // required script hash
+	Script     []byte            // verification script, nil until known
+	Parameters [][]byte          // signature parameters in neo-gui order, nil entry means not signed
+	Signatures map[string][]byte // collected multi-signature signatures keyed by public key hex, cleared when complete
+}
+
+// SetScript set verification script, only signature and multi-signature scripts are supported
+func (item *ContextItem) SetScript(verification []byte) error {
+	if !bytes.Equal(script.Hash(verification), item.ScriptHash) {
+		return fmt.Errorf("verification script hash mismatch %s", scriptHashString(item.ScriptHash))
+	}
+
+	if _, ok := parseSignatureRedeemScript(verification); ok {
+		item.Script = verification
+		item.Parameters = make([][]byte, 1)
+
+		return nil
+	}
+
+	if m, _, ok := parseMultiSigRedeemScript(verification); ok {
+		item.Script = verification
+		item.Parameters = make([][]byte, m)
+		item.Signatures = make(map[string][]byte)
+
+		return nil
+	}
+
+	return ErrUnsupportedWitness
+}
+
+// Completed check if all parameters are filled
+func (item *ContextItem) Completed() bool {
+	if item.Script == nil {
+		return false
+	}
+
+	for _, parameter := range item.Parameters {
+		if parameter == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addSignature add signature if the public key belongs to the item, return false otherwise
+func (item *ContextItem) addSignature(publicKey []byte, signature []byte) bool {
+	if item.Script == nil {
+		if !bytes.Equal(script.Hash(CreateSignatureRedeemScript(publicKey)), item.ScriptHash) {
+			return false
+		}
+
+		if err := item.SetScript(CreateSignatureRedeemScript(publicKey)); err != nil {
+			return false
+		}
+	}
+
+	if key, ok := parseSignatureRedeemScript(item.Script); ok {
+		if !bytes.Equal(key, publicKey) {
+			return false
+		}
+
+		item.Parameters[0] = signature
+
+		return true
+	}
+
+	m, publicKeys, _ := parseMultiSigRedeemScript(item.Script)
+
+	index := -1
+
+	for i, key := range publicKeys {
+		if bytes.Equal(key, publicKey) {
+			index = i
+		}
+	}
+
+	if index == -1 {
+		return false
+	}
+
+	if item.Completed() {
+		return true
+	}
+
+	item.Signatures[hex.EncodeToString(publicKey)] = signature
+
+	if len(item.Signatures) < m {
+		return true
+	}
+
+	// the parameters are ordered by descending public key index, they are pushed in reverse order
+	var signatures [][]byte
+
+	for i := len(publicKeys) - 1; i >= 0; i-- {
+		if signature, ok := item.Signatures[hex.EncodeToString(publicKeys[i])]; ok {
+			signatures = append(signatures, signature)
+		}
+	}
+
+	copy(item.Parameters, signatures)
+
+	item.Signatures = nil
+
+	return true
+}
+
+func (item *ContextItem) witness() (*Scripts, error) {
+	invocation := script.New("invocation")
+
+	for i := len(item.Parameters) - 1; i >= 0; i-- {
+		invocation.EmitPushBytes(item.Parameters[i])
+	}
+
+	stackScript, err := invocation.Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scripts{
+		StackScript:  stackScript,
+		RedeemScript: item.Script,
+	}, nil
+}
+
+// SigningContext unsigned transaction with the signing state of every required script hash,
+// it is exchanged with neo-gui/neo-cli ContractParametersContext json for offline signing
+type SigningContext struct {
+	Tx    *Transaction   // unsigned transaction
+	Items []*ContextItem // items sorted by script hash
+}
+
+// NewSigningContext create signing context of transaction, the resolver resolves input owners, see ScriptHashesForVerifying
+func NewSigningContext(tx *Transaction, resolver OutputResolver) (*SigningContext, error) {
+	if err := tx.genTxID(); err != nil {
+		return nil, err
+	}
+
+	hashes, err := tx.ScriptHashesForVerifying(resolver)
+
+	if err != nil {
+		return nil, err
+	}
+
+	context := &SigningContext{
+		Tx: tx,
+	}
+
+	for _, scriptHash := range hashes {
+		context.Items = append(context.Items, &ContextItem{
+			ScriptHash: scriptHash,
+		})
+	}
+
+	return context, nil
+}
+
+// Item get the item of script hash
+func (context *SigningContext) Item(scriptHash []byte) *ContextItem {
+	for _, item := range context.Items {
+		if bytes.Equal(item.ScriptHash, scriptHash) {
+			return item
+		}
+	}
+
+	return nil
+}
+
+// AddScript set the verification script of one required script hash, multi-signature scripts must be added before signing
+func (context *SigningContext) AddScript(verification []byte) error {
+	item := context.Item(script.Hash(verification))
+
+	if item == nil {
+		return ErrWitnessUnexpected
+	}
+
+	return item.SetScript(verification)
+}
+
+// AddSignature add signature of public key, the signature is checked against the transaction
+func (context *SigningContext) AddSignature(publicKey []byte, signature []byte) error {
+	if !ecc.VerifySignature(publicKey, context.Tx.SignData, signature) {
+		return ErrInvalidSignature
+	}
+
+	added := false
+
+	for _, item := range context.Items {
+		if item.addSignature(publicKey, signature) {
+			added = true
+		}
+	}
+
+	if !added {
+		return ErrUnknownPublicKey
+	}
+
+	return nil
+}
+
+// Sign sign the transaction with signers and add the signatures
+func (context *SigningContext) Sign(signers ...Signer) error {
+	for _, signer := range signers {
+		signature, err := signer.Sign(context.Tx.SignData)
+
+		if err != nil {
+			return err
+		}
+
+		if err := context.AddSignature(signer.PublicKey(), signature); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Completed check if all required witnesses can be assembled
+func (context *SigningContext) Completed() bool {
+	for _, item := range context.Items {
+		if !item.Completed() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RawTx attach the witnesses to the transaction and return the raw transaction
+func (context *SigningContext) RawTx() ([]byte, error) {
+	if !context.Completed() {
+		return nil, ErrSignNotComplete
+	}
+
+	for _, item := range context.Items {
+		witness, err := item.witness()
+
+		if err != nil {
+			return nil, err
+		}
+
+		context.Tx.AddWitness(witness)
+	}
+
+	return context.Tx.RawTx()
+}
+
+type contextParameterJSON struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+type contextItemJSON struct {
+	Script     string                  `json:"script,omitempty"`
+	Parameters []*contextParameterJSON `json:"parameters"`
+	Signatures map[string]string       `json:"signatures,omitempty"`
+}
+
+type contextJSON struct {
+	Type  string                      `json:"type"`
+	Hex   string                      `json:"hex"`
+	Items map[string]*contextItemJSON `json:"items"`
+}
+
+// MarshalJSON marshal as neo-gui ContractParametersContext
+func (context *SigningContext) MarshalJSON() ([]byte, error) {
+	if err := context.Tx.genTxID(); err != nil {
+		return nil, err
+	}
+
+	name, ok := txTypeNames[context.Tx.Type]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction type 0x%02x", context.Tx.Type)
+	}
+
+	value := &contextJSON{
+		Type:  contextTypePrefix + name,
+		Hex:   hex.EncodeToString(context.Tx.SignData),
+		Items: make(map[string]*contextItemJSON),
+	}
+
+	for _, item := range context.Items {
+		itemJSON := &contextItemJSON{
+			Script:     hex.EncodeToString(item.Script),
+			Parameters: make([]*contextParameterJSON, 0, len(item.Parameters)),
+		}
+
+		for _, parameter := range item.Parameters {
+			itemJSON.Parameters = append(itemJSON.Parameters, &contextParameterJSON{
+				Type:  "Signature",
+				Value: hex.EncodeToString(parameter),
+			})
+		}
+
+		if len(item.Signatures) > 0 {
+			itemJSON.Signatures = make(map[string]string)
+
+			for publicKey, signature := range item.Signatures {
+				itemJSON.Signatures[publicKey] = hex.EncodeToString(signature)
+			}
+		}
+
+		value.Items[scriptHashString(item.ScriptHash)] = itemJSON
+	}
+
+	return json.Marshal(value)
+}
+
+// UnmarshalJSON unmarshal neo-gui ContractParametersContext
+func (context *SigningContext) UnmarshalJSON(data []byte) error {
+	var value contextJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	signData, err := hex.DecodeString(value.Hex)
+
+	if err != nil {
+		return err
+	}
+
+	// the hex is unsigned data, append empty witness list to parse it
+	tx, err := ParseTransaction(append(signData, 0x00))
+
+	if err != nil {
+		return err
+	}
+
+	typeName := value.Type[strings.LastIndex(value.Type, ".")+1:]
+
+	if typeName != txTypeNames[tx.Type] {
+		return fmt.Errorf("context type %s mismatch transaction type %s", value.Type, txTypeNames[tx.Type])
+	}
+
+	result := &SigningContext{
+		Tx: tx,
+	}
+
+	for key, itemJSON := range value.Items {
+		scriptHash, err := parseScriptHash(key)
+
+		if err != nil {
+			return err
+		}
+
+		item := &ContextItem{
+			ScriptHash: scriptHash,
+		}
+
+		if itemJSON.Script != "" {
+			verification, err := hex.DecodeString(itemJSON.Script)
+
+			if err != nil {
+				return err
+			}
+
+			if err := item.SetScript(verification); err != nil {
+				return err
+			}
+
+			if len(itemJSON.Parameters) != len(item.Parameters) {
+				return fmt.Errorf("item %s expect %d parameters got %d", key, len(item.Parameters), len(itemJSON.Parameters))
+			}
+		}
+
+		for i, parameter := range itemJSON.Parameters {
+			if parameter.Type != "Signature" {
+				return fmt.Errorf("unsupported parameter type %s", parameter.Type)
+			}
+
+			if parameter.Value == "" || item.Script == nil {
+				continue
+			}
+
+			if item.Parameters[i], err = hex.DecodeString(parameter.Value); err != nil {
+				return err
+			}
+		}
+
+		if len(itemJSON.Signatures) > 0 && item.Signatures == nil {
+			return fmt.Errorf("unexpected signatures of item %s", key)
+		}
+
+		for publicKey, signature := range itemJSON.Signatures {
+			publicKeyBytes, err := hex.DecodeString(publicKey)
+
+			if err != nil {
+				return err
+			}
+
+			signatureBytes, err := hex.DecodeString(signature)
+
+			if err != nil {
+				return err
+			}
+
+			if !ecc.VerifySignature(publicKeyBytes, tx.SignData, signatureBytes) {
+				return ErrInvalidSignature
+			}
+
+			item.addSignature(publicKeyBytes, signatureBytes)
+		}
+
+		result.Items = append(result.Items, item)
+	}
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		return compareScriptHash(result.Items[i].ScriptHash, result.Items[j].ScriptHash) < 0
+	})
+
+	*context = *result
+
+	return nil
+}
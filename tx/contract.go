@@ -20,10 +20,10 @@ func (tx *ContractTx) Tx() *Transaction {
 }
 
 // CalcInputs .
-func (tx *ContractTx) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO) error {
+func (tx *ContractTx) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO, options ...CalcOption) error {
 	base := (*Transaction)(tx)
 
-	vin, _, err := base.CalcInputs(outputs, unspent)
+	vin, _, err := base.CalcInputs(outputs, unspent, options...)
 
 	if err != nil {
 		return err
@@ -0,0 +1,50 @@
+package tx
+
+import (
+	"fmt"
+	"io"
+)
+
+// readECPoint read serialized ec point, the encoding is kept as is,
+// so the point can be written back byte by byte
+func readECPoint(r *BinReader, field string) []byte {
+	offset := r.Offset
+
+	prefix := r.ReadU8(field)
+
+	var length int
+
+	switch prefix {
+	case 0x00:
+		if r.Err != nil {
+			return nil
+		}
+
+		return []byte{prefix}
+	case 0x02, 0x03:
+		length = 32
+	case 0x04, 0x06, 0x07:
+		length = 64
+	default:
+		r.failAt(offset, field, fmt.Errorf("invalid ec point prefix 0x%02x", prefix))
+		return nil
+	}
+
+	body := r.ReadBytes(field, length)
+
+	if r.Err != nil {
+		return nil
+	}
+
+	return append([]byte{prefix}, body...)
+}
+
+func writeECPoint(writer io.Writer, point []byte) error {
+	if len(point) == 0 {
+		point = []byte{0x00}
+	}
+
+	_, err := writer.Write(point)
+
+	return err
+}
@@ -0,0 +1,68 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/inwecrypto/neogo/script"
+)
+
+// EnrollmentTx legacy consensus candidate enrollment, replaced by StateTx and kept for decoding
+type EnrollmentTx Transaction
+
+// Tx get basic transaction object
+func (tx *EnrollmentTx) Tx() *Transaction {
+	return (*Transaction)(tx)
+}
+
+// PublicKey get enrolled candidate public key
+func (tx *EnrollmentTx) PublicKey() []byte {
+	if enrollment, ok := tx.Extend.(*enrollmentTx); ok {
+		return enrollment.PublicKey
+	}
+
+	return nil
+}
+
+type enrollmentTx struct {
+	PublicKey []byte
+}
+
+func (tx *enrollmentTx) Write(writer io.Writer) error {
+	return writeECPoint(writer, tx.PublicKey)
+}
+
+func (tx *enrollmentTx) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
+
+	tx.PublicKey = readECPoint(r, "pubkey")
+
+	return r.Err
+}
+
+func (tx *enrollmentTx) scriptHashesForVerifying(resolver OutputResolver) ([][]byte, error) {
+	return [][]byte{script.Hash(CreateSignatureRedeemScript(tx.PublicKey))}, nil
+}
+
+type enrollmentJSON struct {
+	PublicKey string `json:"pubkey"`
+}
+
+func (tx *enrollmentTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&enrollmentJSON{
+		PublicKey: hex.EncodeToString(tx.PublicKey),
+	})
+}
+
+func (tx *enrollmentTx) UnmarshalJSON(data []byte) (err error) {
+	var value enrollmentJSON
+
+	if err = json.Unmarshal(data, &value); err != nil {
+		return
+	}
+
+	tx.PublicKey, err = hex.DecodeString(value.PublicKey)
+
+	return
+}
@@ -0,0 +1,284 @@
+package tx
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/inwecrypto/neogo/rpc"
+)
+
+// SingleSigWitnessSize estimated serialized size of single signature witness:
+// varint + PUSHBYTES64 signature, varint + PUSHBYTES33 public key CHECKSIG
+const SingleSigWitnessSize = 1 + 65 + 1 + 35
+
+// MultiSigWitnessSize estimated serialized size of m of n multi-signature witness
+func MultiSigWitnessSize(m, n int) int {
+	invocation := m * 65
+	verification := pushIntSize(m) + n*34 + pushIntSize(n) + 1
+
+	return varintSize(invocation) + invocation + varintSize(verification) + verification
+}
+
+func pushIntSize(n int) int {
+	if n <= 16 {
+		return 1
+	}
+
+	if n < 0x80 {
+		return 2
+	}
+
+	return 3
+}
+
+func varintSize(n int) int {
+	var buff bytes.Buffer
+
+	length := Varint(n)
+
+	length.Write(&buff)
+
+	return buff.Len()
+}
+
+// Size get serialized transaction size, pending is the estimated sizes of the witnesses not attached yet,
+// see SingleSigWitnessSize and MultiSigWitnessSize
+func (tx *Transaction) Size(pending ...int) int {
+	var buff bytes.Buffer
+
+	if err := tx.writeSignData(&buff); err != nil {
+		return 0
+	}
+
+	size := buff.Len() + varintSize(len(tx.Scripts)+len(pending))
+
+	for _, witness := range tx.Scripts {
+		size += varintSize(len(witness.StackScript)) + len(witness.StackScript)
+		size += varintSize(len(witness.RedeemScript)) + len(witness.RedeemScript)
+	}
+
+	for _, witnessSize := range pending {
+		size += witnessSize
+	}
+
+	return size
+}
+
+// FeePolicy network fee policy of neo 2.x nodes, transactions larger than FreeSize
+// without enough network fee are low priority and rejected when the free quota is used up
+type FeePolicy struct {
+	FreeSize   int    // max free transaction size in bytes
+	BaseFee    Fixed8 // min network fee of transaction larger than FreeSize
+	FeePerByte Fixed8 // network fee per byte of transaction larger than FreeSize
+}
+
+// DefaultFeePolicy neo-cli 2.x default policy: transactions above 1024 bytes pay 0.001 GAS + 0.00001 GAS per byte of the whole size
+var DefaultFeePolicy = &FeePolicy{
+	FreeSize:   1024,
+	BaseFee:    Fixed8(100000),
+	FeePerByte: Fixed8(1000),
+}
+
+// Fee get required network fee of transaction size
+func (policy *FeePolicy) Fee(size int) (Fixed8, error) {
+	if size <= policy.FreeSize {
+		return 0, nil
+	}
+
+	fee, err := policy.FeePerByte.Mul(Fixed8(int64(size) * 100000000))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return fee.Add(policy.BaseFee)
+}
+
+// SystemFees system fee of transaction types, the neo 2.x mainnet protocol settings,
+// change it for private networks with different settings
+var SystemFees = map[byte]Fixed8{
+	EnrollmentTransaction: Fixed8(1000 * 100000000),
+	IssueTransaction:      Fixed8(500 * 100000000),
+	PublishTransaction:    Fixed8(500 * 100000000),
+	RegisterTransaction:   Fixed8(10000 * 100000000),
+}
+
+// SystemFee get transaction system fee, the invocation gas of InvocationTransaction
+func (tx *Transaction) SystemFee() Fixed8 {
+	switch tx.Type {
+	case InvocationTransaction:
+		if invocation, ok := tx.Extend.(*invocationTx); ok {
+			return invocation.Gas
+		}
+
+		return 0
+	case IssueTransaction:
+		if tx.Version >= 1 {
+			return 0
+		}
+
+		// issuing NEO and GAS is free
+		free := true
+
+		for _, vout := range tx.Outputs {
+			if vout.Asset != NEOAssert && vout.Asset != GasAssert {
+				free = false
+			}
+		}
+
+		if free {
+			return 0
+		}
+	case StateTransaction:
+		if state, ok := tx.Extend.(*stateTx); ok {
+			return state.systemFee()
+		}
+
+		return 0
+	}
+
+	return SystemFees[tx.Type]
+}
+
+func (tx *Transaction) systemFeeSurplus() []*assetAmount {
+	return []*assetAmount{
+		&assetAmount{asset: GasAssert, amount: tx.SystemFee()},
+	}
+}
+
+// NetworkFee get network fee: GAS inputs - GAS outputs - system fee,
+// claim and miner transactions create GAS and have no network fee
+func (tx *Transaction) NetworkFee(resolver OutputResolver) (Fixed8, error) {
+	if tx.Type == ClaimTransaction || tx.Type == MinerTransaction {
+		return 0, nil
+	}
+
+	if resolver == nil && len(tx.Inputs) > 0 {
+		return 0, fmt.Errorf("%w: network fee requires output resolver", ErrUnresolvedInput)
+	}
+
+	fee := Fixed8(0)
+
+	var err error
+
+	for _, vin := range tx.Inputs {
+		vout, err := resolver.ResolveOutput(vin)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if vout.Asset == GasAssert {
+			if fee, err = fee.Add(vout.Value); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	for _, vout := range tx.Outputs {
+		if vout.Asset == GasAssert {
+			if fee, err = fee.Sub(vout.Value); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return fee.Sub(tx.SystemFee())
+}
+
+// WithNetworkFee pay fixed network fee, the fee is selected as GAS surplus (inputs - outputs)
+func WithNetworkFee(fee Fixed8) CalcOption {
+	return func(options *calcOptions) {
+		options.networkFee = fee
+	}
+}
+
+// WithFeePolicy pay network fee required by policy for the estimated transaction size,
+// witnessSizes is the expected witnesses, default is one single signature witness per required script hash
+func WithFeePolicy(policy *FeePolicy, witnessSizes ...int) CalcOption {
+	return func(options *calcOptions) {
+		options.feePolicy = policy
+		options.witnessSizes = witnessSizes
+	}
+}
+
+// selectInputs select inputs paying outputs, surplus and network fee,
+// the network fee is raised until it satisfies the fee policy of the final size
+func (tx *Transaction) selectInputs(outputs []*Vout, surplus []*assetAmount, unspent []*rpc.UTXO, options *calcOptions) ([]*Vin, []*rpc.UTXO, error) {
+	origin := tx.Outputs[:len(tx.Outputs):len(tx.Outputs)]
+
+	fee := options.networkFee
+
+	for {
+		tx.Outputs = origin
+
+		required := surplus
+
+		if fee > 0 {
+			required = append(surplus[:len(surplus):len(surplus)], &assetAmount{asset: GasAssert, amount: fee})
+		}
+
+		inputs, rest, err := tx.calcInputs(outputs, required, unspent, options)
+
+		// a failed selection leaves the outputs unchanged so that it can be retried
+		if err != nil {
+			tx.Outputs = origin
+
+			return nil, nil, err
+		}
+
+		if options.feePolicy == nil {
+			return inputs, rest, nil
+		}
+
+		requiredFee, err := tx.requiredFee(inputs, options)
+
+		if err != nil {
+			tx.Outputs = origin
+
+			return nil, nil, err
+		}
+
+		if requiredFee <= fee {
+			return inputs, rest, nil
+		}
+
+		fee = requiredFee
+	}
+}
+
+func (tx *Transaction) requiredFee(inputs []*Vin, options *calcOptions) (Fixed8, error) {
+	origin := tx.Inputs
+
+	defer func() {
+		tx.Inputs = origin
+	}()
+
+	tx.Inputs = append(origin[:len(origin):len(origin)], inputs...)
+
+	witnessSizes := options.witnessSizes
+
+	if len(witnessSizes) == 0 {
+		hashes, err := tx.ScriptHashesForVerifying(nil)
+
+		if err != nil {
+			return 0, err
+		}
+
+		for range hashes {
+			witnessSizes = append(witnessSizes, SingleSigWitnessSize)
+		}
+	}
+
+	fee, err := options.feePolicy.Fee(tx.Size(witnessSizes...))
+
+	if err != nil {
+		return 0, err
+	}
+
+	if fee < options.networkFee {
+		fee = options.networkFee
+	}
+
+	return fee, nil
+}
@@ -1,71 +1,16 @@
 package tx
 
-import (
-	"encoding/binary"
-	"fmt"
-	"io"
-	"math/big"
-	"strconv"
-)
+import "github.com/inwecrypto/neogo/rpc"
 
-// Fixed8 fixed point number
-type Fixed8 int64
+// Fixed8 fixed point number, shared with rpc package so that rpc results decode straight into it
+type Fixed8 = rpc.Fixed8
 
-// MakeFixed8 .
+// MakeFixed8 convert float64 to fixed8, prefer ParseFixed8 for exact values
 func MakeFixed8(val float64) Fixed8 {
-	return trunc(val)
+	return rpc.MakeFixed8(val)
 }
 
-// Int convert to big.Int object
-func (fixed8 *Fixed8) Int() *big.Int {
-	return big.NewInt(int64(*fixed8))
-}
-
-func (fixed8 *Fixed8) Write(writer io.Writer) error {
-	data := make([]byte, 8)
-
-	binary.LittleEndian.PutUint64(data, uint64(*fixed8))
-
-	_, err := writer.Write(data)
-
-	return err
-}
-
-func (fixed8 *Fixed8) Read(reader io.Reader) error {
-
-	data := make([]byte, 8)
-
-	_, err := reader.Read(data)
-
-	if err != nil {
-		return err
-	}
-
-	*fixed8 = Fixed8(binary.LittleEndian.Uint64(data))
-
-	return nil
-}
-
-// Float64 convert fixe8 to float64
-func (fixed8 *Fixed8) Float64() float64 {
-	valstr := fmt.Sprintf("%.8f", float64(*fixed8)/100000000)
-
-	r, _ := strconv.ParseFloat(valstr, 8)
-
-	return r
-}
-
-func (fixed8 *Fixed8) String() string {
-	return fmt.Sprintf("%.8f", float64(*fixed8)/100000000)
-}
-
-func trunc(val float64) Fixed8 {
-
-	val = val * 100000000
-
-	valstr := fmt.Sprintf("%.0f", val)
-
-	r, _ := strconv.ParseFloat(valstr, 8)
-
-	return Fixed8(r)
+// ParseFixed8 parse decimal string to fixed8
+func ParseFixed8(val string) (Fixed8, error) {
+	return rpc.ParseFixed8(val)
 }
@@ -1,30 +1,37 @@
 package tx
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 
 	"github.com/inwecrypto/neogo/rpc"
-	"bytes"
 )
 
 // InvocationTx .
 type InvocationTx Transaction
 
 type invocationTx struct {
-	Script []byte `json:"script"`
+	legacy bool // version 0 invocation transaction has no gas field
+	Script []byte
+	Gas    Fixed8
+}
+
+type invocationJSON struct {
+	Script string `json:"script"`
 	Gas    Fixed8 `json:"gas"`
 }
 
 // NewInvocationTx .
-func NewInvocationTx(script []byte, gas float64, fromScriptHash []byte, nonce []byte) *InvocationTx {
+func NewInvocationTx(script []byte, gas Fixed8, fromScriptHash []byte, nonce []byte) *InvocationTx {
 	tx := &InvocationTx{
 		Type:    InvocationTransaction,
 		Version: 1,
 		Extend: &invocationTx{
 			Script: script,
-			Gas:    MakeFixed8(gas),
+			Gas:    gas,
 		},
 	}
 
@@ -48,7 +55,7 @@ func BuidInvocationTx(data []byte) (*InvocationTx, error) {
 		Version: 1,
 		Extend: &invocationTx{
 			Script: []byte{},
-			Gas:    MakeFixed8(0),
+			Gas:    Fixed8(0),
 		},
 	}
 
@@ -62,121 +69,88 @@ func BuidInvocationTx(data []byte) (*InvocationTx, error) {
 
 // JSON .
 func (tx *invocationTx) JSON() string {
-	return fmt.Sprintf(`{ "script":"%s","gas":%d }`, hex.EncodeToString(tx.Script), tx.Gas)
-}
+	data, _ := json.Marshal(tx)
 
-// Tx .
-func (tx *InvocationTx) Tx() *Transaction {
-	return (*Transaction)(tx)
+	return string(data)
 }
 
-// CalcInputs .
-func (tx *InvocationTx) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO) error {
-	invocation := tx.Extend.(*invocationTx)
-
-	base := (*Transaction)(tx)
+func (tx *invocationTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&invocationJSON{
+		Script: hex.EncodeToString(tx.Script),
+		Gas:    tx.Gas,
+	})
+}
 
-	inputs, unselected, err := base.CalcInputs(outputs, unspent)
+func (tx *invocationTx) UnmarshalJSON(data []byte) (err error) {
+	var value invocationJSON
 
-	if err != nil {
-		return err
+	if err = json.Unmarshal(data, &value); err != nil {
+		return
 	}
 
-	tx.Inputs = inputs
-
-	unspent = unselected
+	tx.Gas = value.Gas
 
-	// if invocation.Gas.Float64() < 1 {
-	// 	invocation.Gas = Fixed8(0) // zero gas
-	// 	for _, utxo := range unspent {
-	// 		if utxo.Vout.Asset == GasAssert {
-	// 			val, err := utxo.Value()
-	// 			if err != nil {
-	// 				return err
-	// 			}
+	tx.Script, err = hex.DecodeString(value.Script)
 
-	// 			tx.Outputs = append(tx.Outputs, &Vout{
-	// 				Asset:   GasAssert,
-	// 				Value:   MakeFixed8(val),
-	// 				Address: utxo.Vout.Address,
-	// 			})
-
-	// 			tx.Inputs = append(tx.Inputs, &Vin{
-	// 				Tx: utxo.TransactionID,
-	// 				N:  uint16(utxo.Vout.N),
-	// 			})
+	return
+}
 
-	// 			return nil
-	// 		}
-	// 	}
-	// }
+// Tx .
+func (tx *InvocationTx) Tx() *Transaction {
+	return (*Transaction)(tx)
+}
 
-	amount := invocation.Gas.Float64()
+// CalcInputs calculate inputs for outputs and the invocation gas (system fee)
+func (tx *InvocationTx) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO, options ...CalcOption) error {
+	base := (*Transaction)(tx)
 
-	selected, selectedAmount, err := calcTxInput(amount, GasAssert, unspent)
+	inputs, _, err := base.selectInputs(outputs, base.systemFeeSurplus(), unspent, newCalcOptions(options))
 
 	if err != nil {
 		return err
 	}
 
-	if selectedAmount < amount {
-		return ErrNoUTXO
-	}
-
-	for _, utxo := range selected {
-		tx.Inputs = append(tx.Inputs, &Vin{
-			Tx: utxo.TransactionID,
-			N:  uint16(utxo.Vout.N),
-		})
-	}
-
-	if selectedAmount > amount {
-		tx.Outputs = append(tx.Outputs, &Vout{
-			Asset:   GasAssert,
-			Value:   MakeFixed8(selectedAmount - amount),
-			Address: selected[0].Vout.Address,
-		})
-	}
+	tx.Inputs = inputs
 
 	return nil
 }
 
 func (tx *invocationTx) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
 
-	length := Varint(len(tx.Script))
+	w.WriteVarBytes(tx.Script)
 
-	if err := length.Write(writer); err != nil {
-		return err
+	if !tx.legacy {
+		w.WriteSerializable(&tx.Gas)
 	}
 
-	_, err := writer.Write(tx.Script)
-
-	if err != nil {
-		return err
-	}
-
-	return tx.Gas.Write(writer)
+	return w.Err
 }
 
 func (tx *invocationTx) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
 
-	var length Varint
+	offset := r.Offset
 
-	if err := length.Read(reader); err != nil {
-		return err
+	tx.Script = r.ReadVarBytes("script", 65536)
+
+	if r.Err == nil && len(tx.Script) == 0 {
+		r.failAt(offset, "script", fmt.Errorf("empty invocation script"))
 	}
 
-	buff := make([]byte, int(length))
+	if tx.legacy {
+		return r.Err
+	}
 
-	_, err := reader.Read(buff)
+	offset = r.Offset
 
-	if err != nil {
-		return err
-	}
+	r.ReadSerializable("gas", &tx.Gas)
 
-	tx.Script = buff
+	if r.Err == nil && tx.Gas < 0 {
+		r.failAt(offset, "gas", fmt.Errorf("negative invocation gas %s", tx.Gas))
+	}
 
-	return tx.Gas.Read(reader)
+	return r.Err
 }
 
 // ToInvocationAddress neo wallet address to invocation address
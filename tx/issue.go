@@ -0,0 +1,55 @@
+package tx
+
+import "github.com/inwecrypto/neogo/rpc"
+
+// IssueTx asset issue transaction, the issued outputs are not covered by inputs
+type IssueTx Transaction
+
+// NewIssueTx create version 1 issue transaction which is free of system fee,
+// admin is the asset admin address, its witness is required by a script attribute
+func NewIssueTx(admin string, outputs []*Vout) (*IssueTx, error) {
+	adminScriptHash, err := decodeAddress(admin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vout := range outputs {
+		if _, err := decodeAddress(vout.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	tx := &IssueTx{
+		Type:    IssueTransaction,
+		Version: 1,
+		Outputs: outputs,
+	}
+
+	tx.Attributes = append(tx.Attributes, &Attribute{
+		Usage: Script,
+		Data:  adminScriptHash,
+	})
+
+	return tx, nil
+}
+
+// Tx get basic transaction object
+func (tx *IssueTx) Tx() *Transaction {
+	return (*Transaction)(tx)
+}
+
+// CalcInputs calculate GAS inputs for the system fee and the network fee options
+func (tx *IssueTx) CalcInputs(unspent []*rpc.UTXO, options ...CalcOption) error {
+	base := tx.Tx()
+
+	inputs, _, err := base.selectInputs(nil, base.systemFeeSurplus(), unspent, newCalcOptions(options))
+
+	if err != nil {
+		return err
+	}
+
+	tx.Inputs = inputs
+
+	return nil
+}
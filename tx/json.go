@@ -0,0 +1,339 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var txTypeNames = map[byte]string{
+	MinerTransaction:      "MinerTransaction",
+	IssueTransaction:      "IssueTransaction",
+	ClaimTransaction:      "ClaimTransaction",
+	EnrollmentTransaction: "EnrollmentTransaction",
+	RegisterTransaction:   "RegisterTransaction",
+	ContractTransaction:   "ContractTransaction",
+	StateTransaction:      "StateTransaction",
+	PublishTransaction:    "PublishTransaction",
+	InvocationTransaction: "InvocationTransaction",
+}
+
+var usageNames = map[byte]string{
+	ContractHash:   "ContractHash",
+	ECDH02:         "ECDH02",
+	ECDH03:         "ECDH03",
+	Script:         "Script",
+	Vote:           "Vote",
+	CertURL:        "CertUrl",
+	DescriptionURL: "DescriptionUrl",
+	Description:    "Description",
+	Remark:         "Remark",
+}
+
+func init() {
+	for i := byte(1); i <= 15; i++ {
+		usageNames[Hash1+i-1] = fmt.Sprintf("Hash%d", i)
+		usageNames[Remark+i] = fmt.Sprintf("Remark%d", i)
+	}
+}
+
+func lookupName(names map[byte]string, name string) (byte, error) {
+	for value, target := range names {
+		if target == name {
+			return value, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown name %s", name)
+}
+
+// marshalName marshal enum value as name, unknown value as number
+func marshalName(names map[byte]string, value byte) json.RawMessage {
+	if name, ok := names[value]; ok {
+		data, _ := json.Marshal(name)
+		return data
+	}
+
+	data, _ := json.Marshal(value)
+
+	return data
+}
+
+// unmarshalName accept both name and number
+func unmarshalName(names map[byte]string, data json.RawMessage) (byte, error) {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err == nil {
+		return lookupName(names, name)
+	}
+
+	var value byte
+
+	err := json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+func hashString(hash string) string {
+	return "0x" + strings.TrimPrefix(hash, "0x")
+}
+
+// scriptHashString script hash as neo UInt160 string
+func scriptHashString(scriptHash []byte) string {
+	return "0x" + hex.EncodeToString(reverseBytes(append([]byte{}, scriptHash...)))
+}
+
+func parseScriptHash(data string) ([]byte, error) {
+	scriptHash, err := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scriptHash) != 20 {
+		return nil, fmt.Errorf("invalid script hash %s", data)
+	}
+
+	return reverseBytes(scriptHash), nil
+}
+
+type attributeJSON struct {
+	Usage json.RawMessage `json:"usage"`
+	Data  string          `json:"data"`
+}
+
+// MarshalJSON implement json.Marshaler
+func (attr *Attribute) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&attributeJSON{
+		Usage: marshalName(usageNames, attr.Usage),
+		Data:  hex.EncodeToString(attr.Data),
+	})
+}
+
+// UnmarshalJSON implement json.Unmarshaler
+func (attr *Attribute) UnmarshalJSON(data []byte) error {
+	var value attributeJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	usage, err := unmarshalName(usageNames, value.Usage)
+
+	if err != nil {
+		return err
+	}
+
+	attr.Usage = usage
+
+	attr.Data, err = hex.DecodeString(value.Data)
+
+	return err
+}
+
+type vinJSON struct {
+	TxID string `json:"txid"`
+	Vout uint16 `json:"vout"`
+}
+
+// MarshalJSON implement json.Marshaler
+func (vin *Vin) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&vinJSON{
+		TxID: hashString(vin.Tx),
+		Vout: vin.N,
+	})
+}
+
+// UnmarshalJSON implement json.Unmarshaler
+func (vin *Vin) UnmarshalJSON(data []byte) error {
+	var value vinJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	vin.Tx = hashString(value.TxID)
+	vin.N = value.Vout
+
+	return nil
+}
+
+type voutJSON struct {
+	N       *int   `json:"n,omitempty"`
+	Asset   string `json:"asset"`
+	Value   Fixed8 `json:"value"`
+	Address string `json:"address"`
+}
+
+// MarshalJSON implement json.Marshaler
+func (vout *Vout) MarshalJSON() ([]byte, error) {
+	return json.Marshal(vout.toJSON(nil))
+}
+
+func (vout *Vout) toJSON(n *int) *voutJSON {
+	return &voutJSON{
+		N:       n,
+		Asset:   hashString(vout.Asset),
+		Value:   vout.Value,
+		Address: vout.Address,
+	}
+}
+
+// UnmarshalJSON implement json.Unmarshaler
+func (vout *Vout) UnmarshalJSON(data []byte) error {
+	var value voutJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	vout.Asset = hashString(value.Asset)
+	vout.Value = value.Value
+	vout.Address = value.Address
+
+	return nil
+}
+
+type scriptsJSON struct {
+	Invocation   string `json:"invocation"`
+	Verification string `json:"verification"`
+}
+
+// MarshalJSON implement json.Marshaler
+func (scripts *Scripts) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&scriptsJSON{
+		Invocation:   hex.EncodeToString(scripts.StackScript),
+		Verification: hex.EncodeToString(scripts.RedeemScript),
+	})
+}
+
+// UnmarshalJSON implement json.Unmarshaler
+func (scripts *Scripts) UnmarshalJSON(data []byte) error {
+	var value scriptsJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	var err error
+
+	if scripts.StackScript, err = hex.DecodeString(value.Invocation); err != nil {
+		return err
+	}
+
+	scripts.RedeemScript, err = hex.DecodeString(value.Verification)
+
+	return err
+}
+
+type transactionJSON struct {
+	TxID       string          `json:"txid"`
+	Size       int             `json:"size"`
+	Type       json.RawMessage `json:"type"`
+	Version    byte            `json:"version"`
+	Attributes []*Attribute    `json:"attributes"`
+	Vin        []*Vin          `json:"vin"`
+	Vout       []*voutJSON     `json:"vout"`
+	SysFee     Fixed8          `json:"sys_fee"`
+	Scripts    []*Scripts      `json:"scripts"`
+}
+
+// MarshalJSON marshal transaction as neo-cli getrawtransaction verbose output,
+// the exclusive data fields are merged into the transaction object
+func (tx *Transaction) MarshalJSON() ([]byte, error) {
+	if err := tx.genTxID(); err != nil {
+		return nil, err
+	}
+
+	value := &transactionJSON{
+		TxID:       hashString(tx.TxID),
+		Size:       tx.Size(),
+		Type:       marshalName(txTypeNames, tx.Type),
+		Version:    tx.Version,
+		Attributes: make([]*Attribute, 0),
+		Vin:        make([]*Vin, 0),
+		Vout:       make([]*voutJSON, 0),
+		SysFee:     tx.SystemFee(),
+		Scripts:    make([]*Scripts, 0),
+	}
+
+	value.Attributes = append(value.Attributes, tx.Attributes...)
+	value.Vin = append(value.Vin, tx.Inputs...)
+	value.Scripts = append(value.Scripts, tx.Scripts...)
+
+	for i, vout := range tx.Outputs {
+		n := i
+		value.Vout = append(value.Vout, vout.toJSON(&n))
+	}
+
+	data, err := json.Marshal(value)
+
+	if err != nil || tx.Extend == nil {
+		return data, err
+	}
+
+	extend, err := json.Marshal(tx.Extend)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(extend) <= 2 || extend[0] != '{' {
+		return nil, fmt.Errorf("exclusive data of %s must marshal as json object", txTypeNames[tx.Type])
+	}
+
+	// merge exclusive data fields into transaction object
+	return append(append(data[:len(data)-1], ','), extend[1:]...), nil
+}
+
+// UnmarshalJSON unmarshal neo-cli getrawtransaction verbose output, the txid is checked if present
+func (tx *Transaction) UnmarshalJSON(data []byte) error {
+	var value transactionJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	txType, err := unmarshalName(txTypeNames, value.Type)
+
+	if err != nil {
+		return err
+	}
+
+	*tx = Transaction{
+		Type:       txType,
+		Version:    value.Version,
+		Attributes: value.Attributes,
+		Inputs:     value.Vin,
+		Scripts:    value.Scripts,
+	}
+
+	for _, vout := range value.Vout {
+		tx.Outputs = append(tx.Outputs, &Vout{
+			Asset:   hashString(vout.Asset),
+			Value:   vout.Value,
+			Address: vout.Address,
+		})
+	}
+
+	if tx.Extend, err = newExtend(tx.Type, tx.Version); err != nil {
+		return err
+	}
+
+	if tx.Extend != nil {
+		if err := json.Unmarshal(data, tx.Extend); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.genTxID(); err != nil {
+		return err
+	}
+
+	if value.TxID != "" && !sameTxID(value.TxID, tx.TxID) {
+		return fmt.Errorf("txid mismatch, expect %s got %s", value.TxID, hashString(tx.TxID))
+	}
+
+	return nil
+}
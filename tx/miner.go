@@ -0,0 +1,50 @@
+package tx
+
+import "io"
+
+// MinerTx the first transaction of every block, paying network fees to the speaker without inputs
+type MinerTx Transaction
+
+// NewMinerTx create miner transaction with block nonce
+func NewMinerTx(nonce uint32) *MinerTx {
+	return &MinerTx{
+		Type: MinerTransaction,
+		Extend: &minerTx{
+			Nonce: nonce,
+		},
+	}
+}
+
+// Tx get basic transaction object
+func (tx *MinerTx) Tx() *Transaction {
+	return (*Transaction)(tx)
+}
+
+// Nonce get block nonce
+func (tx *MinerTx) Nonce() uint32 {
+	if miner, ok := tx.Extend.(*minerTx); ok {
+		return miner.Nonce
+	}
+
+	return 0
+}
+
+type minerTx struct {
+	Nonce uint32 `json:"nonce"`
+}
+
+func (tx *minerTx) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
+
+	w.WriteUint32(tx.Nonce)
+
+	return w.Err
+}
+
+func (tx *minerTx) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
+
+	tx.Nonce = r.ReadUint32("nonce")
+
+	return r.Err
+}
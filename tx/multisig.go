@@ -0,0 +1,235 @@
+package tx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/inwecrypto/neogo/script"
+)
+
+// Multi-signature errors
+var (
+	ErrUnknownPublicKey = errors.New("public key is not one of the multi-signature owners")
+	ErrSignNotComplete  = errors.New("multi-signature threshold not reached")
+)
+
+type publicKeySorter [][]byte
+
+func (s publicKeySorter) Len() int      { return len(s) }
+func (s publicKeySorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// Less compare X coordinate first then the y parity prefix, the same order as neo ECPoint
+func (s publicKeySorter) Less(i, j int) bool {
+	if c := bytes.Compare(s[i][1:], s[j][1:]); c != 0 {
+		return c < 0
+	}
+
+	return s[i][0] < s[j][0]
+}
+
+func sortPublicKeys(publicKeys [][]byte) ([][]byte, error) {
+	sorted := make([][]byte, 0, len(publicKeys))
+
+	for _, publicKey := range publicKeys {
+		if len(publicKey) != 33 || (publicKey[0] != 0x02 && publicKey[0] != 0x03) {
+			return nil, fmt.Errorf("invalid compressed public key %x", publicKey)
+		}
+
+		sorted = append(sorted, publicKey)
+	}
+
+	sort.Sort(publicKeySorter(sorted))
+
+	for i := 1; i < len(sorted); i++ {
+		if bytes.Equal(sorted[i-1], sorted[i]) {
+			return nil, fmt.Errorf("duplicate public key %x", sorted[i])
+		}
+	}
+
+	return sorted, nil
+}
+
+// CreateMultiSigRedeemScript create m of n verification script: PUSH m, public keys..., PUSH n, CHECKMULTISIG,
+// the public keys are sorted as neo does, so the script hash matches neo-cli's multi-signature address
+func CreateMultiSigRedeemScript(m int, publicKeys [][]byte) ([]byte, error) {
+	if m < 1 || m > len(publicKeys) || len(publicKeys) > 1024 {
+		return nil, fmt.Errorf("invalid multi-signature parameters m %d n %d", m, len(publicKeys))
+	}
+
+	sorted, err := sortPublicKeys(publicKeys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	redeemScript := script.New("multisig")
+
+	redeemScript.EmitPushInteger(big.NewInt(int64(m)))
+
+	for _, publicKey := range sorted {
+		redeemScript.EmitPushBytes(publicKey)
+	}
+
+	redeemScript.
+		EmitPushInteger(big.NewInt(int64(len(sorted)))).
+		Emit(script.CHECKMULTISIG, nil)
+
+	return redeemScript.Bytes()
+}
+
+// MultiSigWitness collect partial signatures of m of n multi-signature account
+type MultiSigWitness struct {
+	M          int      // signature threshold
+	PublicKeys [][]byte // owner public keys in script order
+	Signatures [][]byte // signatures aligned with PublicKeys, nil means not signed yet
+}
+
+// NewMultiSigWitness create m of n multi-signature witness collector
+func NewMultiSigWitness(m int, publicKeys [][]byte) (*MultiSigWitness, error) {
+	if _, err := CreateMultiSigRedeemScript(m, publicKeys); err != nil {
+		return nil, err
+	}
+
+	sorted, _ := sortPublicKeys(publicKeys)
+
+	return &MultiSigWitness{
+		M:          m,
+		PublicKeys: sorted,
+		Signatures: make([][]byte, len(sorted)),
+	}, nil
+}
+
+// RedeemScript get verification script
+func (witness *MultiSigWitness) RedeemScript() ([]byte, error) {
+	return CreateMultiSigRedeemScript(witness.M, witness.PublicKeys)
+}
+
+// ScriptHash get multi-signature account script hash
+func (witness *MultiSigWitness) ScriptHash() ([]byte, error) {
+	redeemScript, err := witness.RedeemScript()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return script.Hash(redeemScript), nil
+}
+
+// Address get multi-signature account address
+func (witness *MultiSigWitness) Address() (string, error) {
+	scriptHash, err := witness.ScriptHash()
+
+	if err != nil {
+		return "", err
+	}
+
+	return encodeAddress(scriptHash), nil
+}
+
+// AddSignature add one owner's signature, the signature is placed at the owner's public key position
+func (witness *MultiSigWitness) AddSignature(publicKey []byte, signature []byte) error {
+	if len(signature) != 64 {
+		return fmt.Errorf("invalid signature length %d", len(signature))
+	}
+
+	for i, target := range witness.PublicKeys {
+		if bytes.Equal(target, publicKey) {
+			witness.Signatures[i] = signature
+			return nil
+		}
+	}
+
+	return ErrUnknownPublicKey
+}
+
+// Signed get collected signature count
+func (witness *MultiSigWitness) Signed() int {
+	count := 0
+
+	for _, signature := range witness.Signatures {
+		if signature != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Complete check if the threshold is reached
+func (witness *MultiSigWitness) Complete() bool {
+	return witness.Signed() >= witness.M
+}
+
+// Scripts assemble the witness, the first M signatures are pushed in public key order
+func (witness *MultiSigWitness) Scripts() (*Scripts, error) {
+	if !witness.Complete() {
+		return nil, ErrSignNotComplete
+	}
+
+	stackScript := script.New("multisig")
+
+	count := 0
+
+	for _, signature := range witness.Signatures {
+		if signature == nil {
+			continue
+		}
+
+		stackScript.EmitPushBytes(signature)
+
+		count++
+
+		if count == witness.M {
+			break
+		}
+	}
+
+	stackScriptBytes, err := stackScript.Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	redeemScript, err := witness.RedeemScript()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scripts{
+		StackScript:  stackScriptBytes,
+		RedeemScript: redeemScript,
+	}, nil
+}
+
+// SignPartial sign the transaction with one owner of multi-signature account, return the signature
+func (tx *Transaction) SignPartial(signer Signer) ([]byte, error) {
+	if err := tx.genTxID(); err != nil {
+		return nil, err
+	}
+
+	return signer.Sign(tx.SignData)
+}
+
+// SignMultiSig attach the completed multi-signature witness, witnesses of other accounts are kept,
+// return raw transaction and txid
+func (tx *Transaction) SignMultiSig(witness *MultiSigWitness) ([]byte, string, error) {
+	scripts, err := witness.Scripts()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	tx.AddWitness(scripts)
+
+	rawTx, err := tx.RawTx()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rawTx, tx.TxID, nil
+}
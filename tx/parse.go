@@ -0,0 +1,115 @@
+package tx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// newExtend create the exclusive data object for transaction type,
+// contract and issue transaction have no exclusive data
+func newExtend(txType byte, version byte) (Serializable, error) {
+	switch txType {
+	case MinerTransaction:
+		return &minerTx{}, nil
+	case IssueTransaction, ContractTransaction:
+		return nil, nil
+	case ClaimTransaction:
+		return &claimTx{}, nil
+	case EnrollmentTransaction:
+		return &enrollmentTx{}, nil
+	case RegisterTransaction:
+		return &registerTx{}, nil
+	case StateTransaction:
+		return &stateTx{}, nil
+	case PublishTransaction:
+		if version > 1 {
+			return nil, fmt.Errorf("unsupported publish transaction version %d", version)
+		}
+
+		return &publishTx{version: version}, nil
+	case InvocationTransaction:
+		if version > 1 {
+			return nil, fmt.Errorf("unsupported invocation transaction version %d", version)
+		}
+
+		return &invocationTx{legacy: version == 0}, nil
+	}
+
+	return nil, fmt.Errorf("unknown transaction type 0x%02x", txType)
+}
+
+// ParseTransaction decode raw transaction, the exclusive data type is selected by the transaction type
+func ParseTransaction(raw []byte) (*Transaction, error) {
+	reader := bytes.NewReader(raw)
+
+	tx, err := ReadTransaction(reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("unexpected %d bytes after transaction", reader.Len())
+	}
+
+	return tx, nil
+}
+
+// ReadTransaction read one transaction from reader
+func ReadTransaction(reader io.Reader) (*Transaction, error) {
+	tx := &Transaction{}
+
+	if err := tx.Read(reader); err != nil {
+		return nil, err
+	}
+
+	if err := tx.genTxID(); err != nil {
+		return nil, err
+	}
+
+	var buff bytes.Buffer
+
+	if err := tx.Write(&buff); err != nil {
+		return nil, err
+	}
+
+	tx.RawData = buff.Bytes()
+
+	return tx, nil
+}
+
+// Hash get transaction hash as little endian UInt256 bytes, the txid is the reversed hex
+func (tx *Transaction) Hash() ([]byte, error) {
+	if err := tx.genTxID(); err != nil {
+		return nil, err
+	}
+
+	hash, err := decodeHash(tx.TxID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reverseBytes(hash), nil
+}
+
+// genTxID fill SignData and TxID fields
+func (tx *Transaction) genTxID() error {
+	var buff bytes.Buffer
+
+	if err := tx.writeSignData(&buff); err != nil {
+		return err
+	}
+
+	tx.SignData = buff.Bytes()
+
+	txid := sha256.Sum256(tx.SignData)
+	txid = sha256.Sum256(txid[:])
+
+	tx.TxID = hex.EncodeToString(reverseBytes(txid[:]))
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/inwecrypto/neogo/script"
+)
+
+var parameterTypeNames = map[byte]string{
+	0x00: "Signature",
+	0x01: "Boolean",
+	0x02: "Integer",
+	0x03: "Hash160",
+	0x04: "Hash256",
+	0x05: "ByteArray",
+	0x06: "PublicKey",
+	0x07: "String",
+	0x10: "Array",
+	0xf0: "InteropInterface",
+	0xff: "Void",
+}
+
+// PublishTx deprecated contract deployment transaction, kept for decoding historical blocks
+type PublishTx Transaction
+
+// Tx get basic transaction object
+func (tx *PublishTx) Tx() *Transaction {
+	return (*Transaction)(tx)
+}
+
+func (tx *PublishTx) contract() *publishTx {
+	if contract, ok := tx.Extend.(*publishTx); ok {
+		return contract
+	}
+
+	return &publishTx{}
+}
+
+// Script get published contract script
+func (tx *PublishTx) Script() []byte {
+	return tx.contract().Script
+}
+
+// ContractHash get published contract script hash
+func (tx *PublishTx) ContractHash() []byte {
+	return script.Hash(tx.contract().Script)
+}
+
+// NeedStorage check if the contract uses storage, always false before version 1
+func (tx *PublishTx) NeedStorage() bool {
+	return tx.contract().NeedStorage
+}
+
+// Name get contract name
+func (tx *PublishTx) Name() string {
+	return tx.contract().Name
+}
+
+type publishTx struct {
+	version       byte // publish transaction version, NeedStorage exists since version 1
+	Script        []byte
+	ParameterList []byte // contract parameter types
+	ReturnType    byte
+	NeedStorage   bool
+	Name          string
+	CodeVersion   string
+	Author        string
+	Email         string
+	Description   string
+}
+
+func (tx *publishTx) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
+
+	w.WriteVarBytes(tx.Script)
+	w.WriteVarBytes(tx.ParameterList)
+	w.WriteU8(tx.ReturnType)
+
+	if tx.version >= 1 {
+		needStorage := byte(0)
+
+		if tx.NeedStorage {
+			needStorage = 1
+		}
+
+		w.WriteU8(needStorage)
+	}
+
+	for _, field := range []string{tx.Name, tx.CodeVersion, tx.Author, tx.Email, tx.Description} {
+		w.WriteVarString(field)
+	}
+
+	return w.Err
+}
+
+func (tx *publishTx) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
+
+	tx.Script = r.ReadVarBytes("script", MaxVarBytesSize)
+	tx.ParameterList = r.ReadVarBytes("parameters", MaxVarBytesSize)
+	tx.ReturnType = r.ReadU8("returntype")
+
+	if tx.version >= 1 {
+		tx.NeedStorage = r.ReadU8("needstorage") != 0
+	}
+
+	tx.Name = r.ReadVarString("name", 252)
+	tx.CodeVersion = r.ReadVarString("version", 252)
+	tx.Author = r.ReadVarString("author", 252)
+	tx.Email = r.ReadVarString("email", 252)
+	tx.Description = r.ReadVarString("description", 65536)
+
+	return r.Err
+}
+
+type codeJSON struct {
+	Hash       string            `json:"hash"`
+	Script     string            `json:"script"`
+	Parameters []json.RawMessage `json:"parameters"`
+	ReturnType json.RawMessage   `json:"returntype"`
+}
+
+type contractJSON struct {
+	Code        *codeJSON `json:"code"`
+	NeedStorage bool      `json:"needstorage"`
+	Name        string    `json:"name"`
+	CodeVersion string    `json:"version"`
+	Author      string    `json:"author"`
+	Email       string    `json:"email"`
+	Description string    `json:"description"`
+}
+
+type publishJSON struct {
+	Contract *contractJSON `json:"contract"`
+}
+
+func (tx *publishTx) MarshalJSON() ([]byte, error) {
+	code := &codeJSON{
+		Hash:       scriptHashString(script.Hash(tx.Script)),
+		Script:     hex.EncodeToString(tx.Script),
+		Parameters: make([]json.RawMessage, 0),
+		ReturnType: marshalName(parameterTypeNames, tx.ReturnType),
+	}
+
+	for _, parameter := range tx.ParameterList {
+		code.Parameters = append(code.Parameters, marshalName(parameterTypeNames, parameter))
+	}
+
+	return json.Marshal(&publishJSON{
+		Contract: &contractJSON{
+			Code:        code,
+			NeedStorage: tx.NeedStorage,
+			Name:        tx.Name,
+			CodeVersion: tx.CodeVersion,
+			Author:      tx.Author,
+			Email:       tx.Email,
+			Description: tx.Description,
+		},
+	})
+}
+
+func (tx *publishTx) UnmarshalJSON(data []byte) error {
+	var value publishJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	if value.Contract == nil || value.Contract.Code == nil {
+		return fmt.Errorf("missing contract field")
+	}
+
+	contract := value.Contract
+
+	var err error
+
+	if tx.Script, err = hex.DecodeString(contract.Code.Script); err != nil {
+		return err
+	}
+
+	if contract.Code.Hash != "" && contract.Code.Hash != scriptHashString(script.Hash(tx.Script)) {
+		return fmt.Errorf("contract hash mismatch %s", contract.Code.Hash)
+	}
+
+	tx.ParameterList = make([]byte, 0, len(contract.Code.Parameters))
+
+	for _, parameter := range contract.Code.Parameters {
+		parameterType, err := unmarshalName(parameterTypeNames, parameter)
+
+		if err != nil {
+			return err
+		}
+
+		tx.ParameterList = append(tx.ParameterList, parameterType)
+	}
+
+	if tx.ReturnType, err = unmarshalName(parameterTypeNames, contract.Code.ReturnType); err != nil {
+		return err
+	}
+
+	tx.NeedStorage = contract.NeedStorage
+	tx.Name = contract.Name
+	tx.CodeVersion = contract.CodeVersion
+	tx.Author = contract.Author
+	tx.Email = contract.Email
+	tx.Description = contract.Description
+
+	return nil
+}
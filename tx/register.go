@@ -0,0 +1,296 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/inwecrypto/neogo/rpc"
+	"github.com/inwecrypto/neogo/script"
+)
+
+// Asset types
+const (
+	CreditFlag     byte = 0x40
+	DutyFlag       byte = 0x80
+	GoverningToken byte = 0x00
+	UtilityToken   byte = 0x01
+	Currency       byte = 0x08
+	Share          byte = DutyFlag | 0x10
+	Invoice        byte = DutyFlag | 0x18
+	Token          byte = CreditFlag | 0x20
+)
+
+var assetTypeNames = map[byte]string{
+	CreditFlag:     "CreditFlag",
+	DutyFlag:       "DutyFlag",
+	GoverningToken: "GoverningToken",
+	UtilityToken:   "UtilityToken",
+	Currency:       "Currency",
+	Share:          "Share",
+	Invoice:        "Invoice",
+	Token:          "Token",
+}
+
+// UnlimitedAmount register asset without total amount limit
+const UnlimitedAmount = Fixed8(-1)
+
+// AssetName localized asset name
+type AssetName struct {
+	Lang string `json:"lang"`
+	Name string `json:"name"`
+}
+
+// RegisterTx asset register transaction, the registered asset id is the transaction hash
+type RegisterTx Transaction
+
+// NewRegisterTx create asset register transaction, owner is the owner public key,
+// admin is the address which is allowed to issue the asset.
+// GoverningToken and UtilityToken are only registered by the genesis block, neo rejects them
+func NewRegisterTx(assetType byte, names []*AssetName, amount Fixed8, precision byte, owner []byte, admin string) (*RegisterTx, error) {
+	switch assetType {
+	case CreditFlag, DutyFlag, GoverningToken, UtilityToken:
+		return nil, fmt.Errorf("invalid asset type 0x%02x", assetType)
+	}
+
+	if _, ok := assetTypeNames[assetType]; !ok {
+		return nil, fmt.Errorf("invalid asset type 0x%02x", assetType)
+	}
+
+	if precision > 8 {
+		return nil, fmt.Errorf("invalid asset precision %d", precision)
+	}
+
+	if amount != UnlimitedAmount {
+		if amount <= 0 {
+			return nil, fmt.Errorf("invalid asset amount %s", amount)
+		}
+
+		unit := int64(1)
+
+		for i := precision; i < 8; i++ {
+			unit *= 10
+		}
+
+		if int64(amount)%unit != 0 {
+			return nil, fmt.Errorf("asset amount %s exceeds precision %d", amount, precision)
+		}
+	}
+
+	// the infinity owner 0x00 is only valid for GoverningToken
+	if _, err := sortPublicKeys([][]byte{owner}); err != nil {
+		return nil, err
+	}
+
+	adminScriptHash, err := decodeAddress(admin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := encodeAssetNames(names)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegisterTx{
+		Type: RegisterTransaction,
+		Extend: &registerTx{
+			AssetType: assetType,
+			Name:      name,
+			Amount:    amount,
+			Precision: precision,
+			Owner:     owner,
+			Admin:     adminScriptHash,
+		},
+	}, nil
+}
+
+func encodeAssetNames(names []*AssetName) (string, error) {
+	if len(names) == 0 {
+		return "", fmt.Errorf("asset name required")
+	}
+
+	var buff bytes.Buffer
+
+	encoder := json.NewEncoder(&buff)
+
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(names); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buff.String()), nil
+}
+
+// Tx get basic transaction object
+func (tx *RegisterTx) Tx() *Transaction {
+	return (*Transaction)(tx)
+}
+
+// AssetID get registered asset id, which changes when the transaction changes
+func (tx *RegisterTx) AssetID() (string, error) {
+	if err := tx.Tx().genTxID(); err != nil {
+		return "", err
+	}
+
+	return hashString(tx.TxID), nil
+}
+
+// CalcInputs calculate inputs for outputs and the register system fee
+func (tx *RegisterTx) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO, options ...CalcOption) error {
+	base := tx.Tx()
+
+	inputs, _, err := base.selectInputs(outputs, base.systemFeeSurplus(), unspent, newCalcOptions(options))
+
+	if err != nil {
+		return err
+	}
+
+	tx.Inputs = inputs
+
+	return nil
+}
+
+type registerTx struct {
+	AssetType byte
+	Name      string // localized names json, e.g. [{"lang":"en","name":"AntShare"}]
+	Amount    Fixed8
+	Precision byte
+	Owner     []byte // owner public key, 0x00 means ECPoint.Infinity
+	Admin     []byte // admin script hash
+}
+
+func (tx *registerTx) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
+
+	w.WriteU8(tx.AssetType)
+	w.WriteVarString(tx.Name)
+	w.WriteSerializable(&tx.Amount)
+	w.WriteU8(tx.Precision)
+
+	if w.Err == nil {
+		w.Fail(writeECPoint(w, tx.Owner))
+	}
+
+	w.WriteBytes(tx.Admin)
+
+	return w.Err
+}
+
+func (tx *registerTx) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
+
+	tx.AssetType = r.ReadU8("type")
+	tx.Name = r.ReadVarString("name", 1024)
+
+	r.ReadSerializable("amount", &tx.Amount)
+
+	tx.Precision = r.ReadU8("precision")
+	tx.Owner = readECPoint(r, "owner")
+	tx.Admin = r.ReadBytes("admin", 20)
+
+	return r.Err
+}
+
+func (tx *registerTx) scriptHashesForVerifying(resolver OutputResolver) ([][]byte, error) {
+	if len(tx.Owner) != 33 {
+		return nil, nil
+	}
+
+	return [][]byte{script.Hash(CreateSignatureRedeemScript(tx.Owner))}, nil
+}
+
+type assetJSON struct {
+	Type      json.RawMessage `json:"type"`
+	Name      json.RawMessage `json:"name"`
+	Amount    Fixed8          `json:"amount"`
+	Precision byte            `json:"precision"`
+	Owner     string          `json:"owner"`
+	Admin     string          `json:"admin"`
+}
+
+type registerJSON struct {
+	Asset *assetJSON `json:"asset"`
+}
+
+// marshalAssetName the localized name is json itself, it is embedded as is when that keeps the exact bytes,
+// otherwise it is marshaled as string so the txid never changes through json
+func marshalAssetName(name string) json.RawMessage {
+	var buff bytes.Buffer
+
+	if err := json.Compact(&buff, []byte(name)); err == nil && buff.String() == name && !strings.ContainsAny(name, "<>&") {
+		return json.RawMessage(name)
+	}
+
+	data, _ := json.Marshal(name)
+
+	return data
+}
+
+func unmarshalAssetName(data json.RawMessage) (string, error) {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err == nil {
+		return name, nil
+	}
+
+	var buff bytes.Buffer
+
+	if err := json.Compact(&buff, data); err != nil {
+		return "", err
+	}
+
+	return buff.String(), nil
+}
+
+func (tx *registerTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&registerJSON{
+		Asset: &assetJSON{
+			Type:      marshalName(assetTypeNames, tx.AssetType),
+			Name:      marshalAssetName(tx.Name),
+			Amount:    tx.Amount,
+			Precision: tx.Precision,
+			Owner:     hex.EncodeToString(tx.Owner),
+			Admin:     encodeAddress(tx.Admin),
+		},
+	})
+}
+
+func (tx *registerTx) UnmarshalJSON(data []byte) error {
+	var value registerJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	if value.Asset == nil {
+		return fmt.Errorf("missing asset field")
+	}
+
+	var err error
+
+	if tx.AssetType, err = unmarshalName(assetTypeNames, value.Asset.Type); err != nil {
+		return err
+	}
+
+	if tx.Name, err = unmarshalAssetName(value.Asset.Name); err != nil {
+		return err
+	}
+
+	tx.Amount = value.Asset.Amount
+	tx.Precision = value.Asset.Precision
+
+	if tx.Owner, err = hex.DecodeString(value.Asset.Owner); err != nil {
+		return err
+	}
+
+	tx.Admin, err = decodeAddress(value.Asset.Admin)
+
+	return err
+}
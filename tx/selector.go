@@ -0,0 +1,278 @@
+package tx
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/inwecrypto/neogo/rpc"
+)
+
+// Coin selection errors
+var (
+	ErrInputLimit   = errors.New("utxo selection exceeds max input count or size budget")
+	ErrNoExactMatch = errors.New("no utxo combination matches the amount exactly")
+)
+
+// InsufficientFundsError returned when the unspent utxos of one asset can't cover the required amount
+type InsufficientFundsError struct {
+	Asset     string // asset id
+	Required  Fixed8 // required amount
+	Available Fixed8 // total amount of unspent utxos
+}
+
+// Short the missing amount
+func (err *InsufficientFundsError) Short() Fixed8 {
+	return err.Required - err.Available
+}
+
+func (err *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("insufficient funds of asset %s: required %s, available %s, short %s",
+		err.Asset, err.Required, err.Available, err.Short())
+}
+
+// CoinSelector select utxos to cover amount, all candidates belong to the same asset,
+// maxInputs <= 0 means no input count limit
+type CoinSelector interface {
+	Select(amount Fixed8, candidates []*rpc.UTXO, maxInputs int) ([]*rpc.UTXO, error)
+}
+
+// Builtin coin selectors
+var (
+	SmallestFirst  CoinSelector = &smallestFirst{}  // spend small utxos first, the historical behavior
+	LargestFirst   CoinSelector = &largestFirst{}   // spend large utxos first
+	MinimizeInputs CoinSelector = &minimizeInputs{} // use as few inputs as possible with minimal change
+)
+
+type smallestFirst struct{}
+
+func (selector *smallestFirst) Select(amount Fixed8, candidates []*rpc.UTXO, maxInputs int) ([]*rpc.UTXO, error) {
+	sorted := sortUTXO(candidates, false)
+
+	return accumulate(amount, sorted, maxInputs)
+}
+
+type largestFirst struct{}
+
+func (selector *largestFirst) Select(amount Fixed8, candidates []*rpc.UTXO, maxInputs int) ([]*rpc.UTXO, error) {
+	sorted := sortUTXO(candidates, true)
+
+	return accumulate(amount, sorted, maxInputs)
+}
+
+type minimizeInputs struct{}
+
+func (selector *minimizeInputs) Select(amount Fixed8, candidates []*rpc.UTXO, maxInputs int) ([]*rpc.UTXO, error) {
+	sorted := sortUTXO(candidates, true)
+
+	largest, err := accumulate(amount, sorted, maxInputs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(largest) == 0 {
+		return largest, nil
+	}
+
+	// keep the largest count-1 utxos, then pick the smallest utxo which still covers the rest
+	count := len(largest)
+
+	selected := sorted[:count-1]
+
+	rest := amount
+
+	for _, utxo := range selected {
+		rest -= utxo.Value()
+	}
+
+	last := sorted[count-1]
+
+	for _, utxo := range sorted[count-1:] {
+		if utxo.Value() >= rest {
+			last = utxo
+		}
+	}
+
+	return append(append([]*rpc.UTXO{}, selected...), last), nil
+}
+
+type branchAndBound struct {
+	fallback CoinSelector
+	maxTries int
+}
+
+// NewBranchAndBound create exact match selector which never generates change output,
+// if there is no exact match the fallback selector is used, or ErrNoExactMatch is returned when fallback is nil
+func NewBranchAndBound(fallback CoinSelector) CoinSelector {
+	return &branchAndBound{
+		fallback: fallback,
+		maxTries: 100000,
+	}
+}
+
+func (selector *branchAndBound) Select(amount Fixed8, candidates []*rpc.UTXO, maxInputs int) ([]*rpc.UTXO, error) {
+	sorted := sortUTXO(candidates, true)
+
+	// remain[i] is the sum of sorted[i:]
+	remain := make([]Fixed8, len(sorted)+1)
+
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remain[i] = remain[i+1] + sorted[i].Value()
+	}
+
+	tries := 0
+
+	var selected []*rpc.UTXO
+
+	var search func(index int, sum Fixed8) bool
+
+	search = func(index int, sum Fixed8) bool {
+		if sum == amount {
+			return true
+		}
+
+		tries++
+
+		if index == len(sorted) || sum > amount || sum+remain[index] < amount || tries > selector.maxTries {
+			return false
+		}
+
+		if maxInputs <= 0 || len(selected) < maxInputs {
+			selected = append(selected, sorted[index])
+
+			if search(index+1, sum+sorted[index].Value()) {
+				return true
+			}
+
+			selected = selected[:len(selected)-1]
+		}
+
+		return search(index+1, sum)
+	}
+
+	if search(0, 0) {
+		return selected, nil
+	}
+
+	if selector.fallback != nil {
+		return selector.fallback.Select(amount, candidates, maxInputs)
+	}
+
+	return nil, ErrNoExactMatch
+}
+
+type randomSelector struct {
+	rand *rand.Rand
+}
+
+// NewRandomSelector create selector which spends utxos in random order,
+// if r is nil a time seeded source is used
+func NewRandomSelector(r *rand.Rand) CoinSelector {
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return &randomSelector{
+		rand: r,
+	}
+}
+
+func (selector *randomSelector) Select(amount Fixed8, candidates []*rpc.UTXO, maxInputs int) ([]*rpc.UTXO, error) {
+	shuffled := append([]*rpc.UTXO{}, candidates...)
+
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := selector.rand.Intn(i + 1)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+
+	return accumulate(amount, shuffled, maxInputs)
+}
+
+func sortUTXO(candidates []*rpc.UTXO, desc bool) []*rpc.UTXO {
+	sorted := append([]*rpc.UTXO{}, candidates...)
+
+	if desc {
+		sort.Stable(sort.Reverse(utxoSorter(sorted)))
+	} else {
+		sort.Stable(utxoSorter(sorted))
+	}
+
+	return sorted
+}
+
+// accumulate take utxos in order until amount is covered
+func accumulate(amount Fixed8, ordered []*rpc.UTXO, maxInputs int) ([]*rpc.UTXO, error) {
+	selected := make([]*rpc.UTXO, 0)
+
+	if amount <= 0 {
+		return selected, nil
+	}
+
+	sum := Fixed8(0)
+
+	for _, utxo := range ordered {
+		if maxInputs > 0 && len(selected) == maxInputs {
+			return nil, ErrInputLimit
+		}
+
+		selected = append(selected, utxo)
+
+		sum += utxo.Value()
+
+		if sum >= amount {
+			return selected, nil
+		}
+	}
+
+	return nil, ErrNoUTXO
+}
+
+type calcOptions struct {
+	selector     CoinSelector
+	change       ChangePolicy
+	maxInputs    int
+	maxSize      int
+	networkFee   Fixed8
+	feePolicy    *FeePolicy
+	witnessSizes []int
+}
+
+// CalcOption CalcInputs option
+type CalcOption func(options *calcOptions)
+
+// WithSelector set coin selection strategy, default is SmallestFirst
+func WithSelector(selector CoinSelector) CalcOption {
+	return func(options *calcOptions) {
+		options.selector = selector
+	}
+}
+
+// WithMaxInputs limit the total input count of transaction
+func WithMaxInputs(maxInputs int) CalcOption {
+	return func(options *calcOptions) {
+		options.maxInputs = maxInputs
+	}
+}
+
+// WithMaxSize limit the unsigned transaction size in bytes
+func WithMaxSize(maxSize int) CalcOption {
+	return func(options *calcOptions) {
+		options.maxSize = maxSize
+	}
+}
+
+func newCalcOptions(options []CalcOption) *calcOptions {
+	result := &calcOptions{
+		selector: SmallestFirst,
+		change:   ChangeToFirstInput,
+	}
+
+	for _, option := range options {
+		option(result)
+	}
+
+	return result
+}
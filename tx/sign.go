@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"crypto/sha256"
-	"encoding/hex"
 	"io"
+	"sort"
 
 	"github.com/apisit/rfc6979"
 	"github.com/inwecrypto/neogo/script"
@@ -47,116 +47,183 @@ func rfc6979Sign(ecdsaPrivateKey *ecdsa.PrivateKey, data []byte) ([]byte, error)
 	return signature, nil
 }
 
-// Sign sign transaction
-func (tx *Transaction) Sign(ecdsaPrivateKey *ecdsa.PrivateKey) ([]byte, string, error) {
-	var buff bytes.Buffer
+// Signer transaction signer, implement it to keep private keys outside of the transaction builder process
+type Signer interface {
+	PublicKey() []byte                // compressed public key
+	ScriptHash() []byte               // script hash of the signer's verification script
+	Sign(data []byte) ([]byte, error) // sign sha256(data), return 64 bytes r|s signature
+}
 
-	if err := tx.writeSignData(&buff); err != nil {
-		return nil, "", err
+// KeySigner in memory private key signer
+type KeySigner struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewKeySigner create signer with in memory private key
+func NewKeySigner(privateKey *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{
+		privateKey: privateKey,
 	}
+}
 
-	tx.SignData = make([]byte, len(buff.Bytes()))
-	copy(tx.SignData, buff.Bytes())
+// PublicKey implement Signer
+func (signer *KeySigner) PublicKey() []byte {
+	return publicKeyToBytes(&signer.privateKey.PublicKey)
+}
 
-	txid := sha256.Sum256(tx.SignData)
-	txid = sha256.Sum256(txid[:])
+// ScriptHash implement Signer
+func (signer *KeySigner) ScriptHash() []byte {
+	return script.Hash(CreateSignatureRedeemScript(signer.PublicKey()))
+}
 
-	tx.TxID = hex.EncodeToString(reverseBytes(txid[:]))
+// Sign implement Signer, using RFC6979 deterministic signature
+func (signer *KeySigner) Sign(data []byte) ([]byte, error) {
+	return rfc6979Sign(signer.privateKey, data)
+}
 
-	sign, err := rfc6979Sign(ecdsaPrivateKey, tx.SignData)
+// CreateSignatureRedeemScript create single signature verification script: PUSHBYTES33 public key, CHECKSIG
+func CreateSignatureRedeemScript(publicKey []byte) []byte {
+	return append(append([]byte{byte(len(publicKey))}, publicKey...), byte(script.CHECKSIG))
+}
 
-	if err != nil {
+// Sign sign transaction with one signer, witnesses of other signers are kept,
+// return raw transaction and txid
+func (tx *Transaction) Sign(signer Signer) ([]byte, string, error) {
+	if err := tx.SignWith(signer); err != nil {
 		return nil, "", err
 	}
 
-	tx.SignResult = sign
-
-	var stackScriptBuffer bytes.Buffer
+	rawTx, err := tx.RawTx()
 
-	signScript := script.New("test")
+	if err != nil {
+		return nil, "", err
+	}
 
-	signScript.EmitPushBytes(sign)
+	return rawTx, tx.TxID, nil
+}
 
-	signScript.Write(&stackScriptBuffer)
+// SignWith add single signature witnesses of signers, the witnesses are kept sorted by script hash
+func (tx *Transaction) SignWith(signers ...Signer) error {
+	if err := tx.genTxID(); err != nil {
+		return err
+	}
 
-	stackScript := stackScriptBuffer.Bytes()
+	for _, signer := range signers {
+		sign, err := signer.Sign(tx.SignData)
 
-	address := publicKeyToBytes(&ecdsaPrivateKey.PublicKey)
+		if err != nil {
+			return err
+		}
 
-	signScript.Reset()
+		tx.SignResult = sign
 
-	signScript.
-		EmitPushBytes(address).
-		Emit(script.CHECKSIG, nil)
+		signScript := script.New("signature")
 
-	var redeemScriptBuffer bytes.Buffer
+		stackScript, err := signScript.EmitPushBytes(sign).Bytes()
 
-	signScript.Write(&redeemScriptBuffer)
+		if err != nil {
+			return err
+		}
 
-	tx.Scripts = []*Scripts{
-		&Scripts{
+		tx.AddWitness(&Scripts{
 			StackScript:  stackScript,
-			RedeemScript: redeemScriptBuffer.Bytes(),
-		},
+			RedeemScript: CreateSignatureRedeemScript(signer.PublicKey()),
+		})
 	}
 
-	var rawTx bytes.Buffer
+	return nil
+}
 
-	if err := tx.Write(&rawTx); err != nil {
-		return nil, "", err
+// AddWitness add witness or replace the witness with the same verification script hash,
+// witnesses are kept sorted by script hash as neo requires
+func (tx *Transaction) AddWitness(witness *Scripts) {
+	scriptHash := script.Hash(witness.RedeemScript)
+
+	for i, target := range tx.Scripts {
+		if bytes.Equal(script.Hash(target.RedeemScript), scriptHash) {
+			tx.Scripts[i] = witness
+			return
+		}
 	}
 
-	return rawTx.Bytes(), tx.TxID, nil
+	tx.Scripts = append(tx.Scripts, witness)
+
+	sort.SliceStable(tx.Scripts, func(i, j int) bool {
+		return compareScriptHash(script.Hash(tx.Scripts[i].RedeemScript), script.Hash(tx.Scripts[j].RedeemScript)) < 0
+	})
 }
 
-func (tx *Transaction) writeSignData(writer io.Writer) error {
-	_, err := writer.Write([]byte{tx.Type, tx.Version})
+// MissingWitnesses get the required script hashes which have no witness yet
+func (tx *Transaction) MissingWitnesses(resolver OutputResolver) ([][]byte, error) {
+	hashes, err := tx.ScriptHashesForVerifying(resolver)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if tx.Extend != nil {
-		if err := tx.Extend.Write(writer); err != nil {
-			return err
+	var missing [][]byte
+
+	for _, scriptHash := range hashes {
+		found := false
+
+		for _, witness := range tx.Scripts {
+			if bytes.Equal(script.Hash(witness.RedeemScript), scriptHash) {
+				found = true
+			}
+		}
+
+		if !found {
+			missing = append(missing, scriptHash)
 		}
 	}
 
-	length := Varint(len(tx.Attributes))
+	return missing, nil
+}
 
-	if err := length.Write(writer); err != nil {
-		return err
+// RawTx serialize transaction with current witnesses, RawData is updated too
+func (tx *Transaction) RawTx() ([]byte, error) {
+	if err := tx.genTxID(); err != nil {
+		return nil, err
 	}
 
-	for _, attr := range tx.Attributes {
-		if err := attr.Write(writer); err != nil {
-			return err
-		}
+	var rawTx bytes.Buffer
+
+	if err := tx.Write(&rawTx); err != nil {
+		return nil, err
 	}
 
-	length = Varint(len(tx.Inputs))
+	tx.RawData = rawTx.Bytes()
 
-	if err := length.Write(writer); err != nil {
-		return err
+	return tx.RawData, nil
+}
+
+func (tx *Transaction) writeSignData(writer io.Writer) error {
+	w := NewBinWriter(writer)
+
+	w.WriteU8(tx.Type)
+	w.WriteU8(tx.Version)
+
+	if tx.Extend != nil {
+		w.WriteSerializable(tx.Extend)
 	}
 
-	for _, input := range tx.Inputs {
-		if err := input.Write(writer); err != nil {
-			return err
-		}
+	w.WriteVarUint(uint64(len(tx.Attributes)))
+
+	for _, attr := range tx.Attributes {
+		w.WriteSerializable(attr)
 	}
 
-	length = Varint(len(tx.Outputs))
+	w.WriteVarUint(uint64(len(tx.Inputs)))
 
-	if err := length.Write(writer); err != nil {
-		return err
+	for _, input := range tx.Inputs {
+		w.WriteSerializable(input)
 	}
 
+	w.WriteVarUint(uint64(len(tx.Outputs)))
+
 	for _, output := range tx.Outputs {
-		if err := output.Write(writer); err != nil {
-			return err
-		}
+		w.WriteSerializable(output)
 	}
 
-	return nil
+	return w.Err
 }
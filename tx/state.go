@@ -0,0 +1,316 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/inwecrypto/neogo/rpc"
+	"github.com/inwecrypto/neogo/script"
+)
+
+// State descriptor types
+const (
+	Account   byte = 0x40
+	Validator byte = 0x48
+)
+
+var stateTypeNames = map[byte]string{
+	Account:   "Account",
+	Validator: "Validator",
+}
+
+// StateDescriptor state transaction descriptor
+type StateDescriptor struct {
+	Type  byte
+	Key   []byte
+	Field string
+	Value []byte
+}
+
+// State descriptor fields
+const (
+	FieldRegistered = "Registered"
+	FieldVotes      = "Votes"
+)
+
+// maxVotes max public keys of one vote
+const maxVotes = 1024
+
+// NewValidatorDescriptor create descriptor to register or unregister consensus candidate
+func NewValidatorDescriptor(publicKey []byte, registered bool) (*StateDescriptor, error) {
+	if _, err := sortPublicKeys([][]byte{publicKey}); err != nil {
+		return nil, err
+	}
+
+	value := byte(0)
+
+	if registered {
+		value = 1
+	}
+
+	return &StateDescriptor{
+		Type:  Validator,
+		Key:   publicKey,
+		Field: FieldRegistered,
+		Value: []byte{value},
+	}, nil
+}
+
+// NewVoteDescriptor create descriptor to vote candidates with the NEO of account, empty publicKeys cancel the votes
+func NewVoteDescriptor(address string, publicKeys [][]byte) (*StateDescriptor, error) {
+	scriptHash, err := decodeAddress(address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(publicKeys) > maxVotes {
+		return nil, fmt.Errorf("too many votes %d", len(publicKeys))
+	}
+
+	// the public keys are validated only, the vote order is kept
+	if _, err := sortPublicKeys(publicKeys); err != nil {
+		return nil, err
+	}
+
+	var buff bytes.Buffer
+
+	length := Varint(len(publicKeys))
+
+	if err := length.Write(&buff); err != nil {
+		return nil, err
+	}
+
+	for _, publicKey := range publicKeys {
+		buff.Write(publicKey)
+	}
+
+	return &StateDescriptor{
+		Type:  Account,
+		Key:   scriptHash,
+		Field: FieldVotes,
+		Value: buff.Bytes(),
+	}, nil
+}
+
+func (descriptor *StateDescriptor) scriptHashForVerifying() ([]byte, error) {
+	switch descriptor.Type {
+	case Account:
+		if len(descriptor.Key) != 20 {
+			return nil, fmt.Errorf("invalid account state key length %d", len(descriptor.Key))
+		}
+
+		return descriptor.Key, nil
+	case Validator:
+		if len(descriptor.Key) != 33 {
+			return nil, fmt.Errorf("invalid validator state key length %d", len(descriptor.Key))
+		}
+
+		return script.Hash(CreateSignatureRedeemScript(descriptor.Key)), nil
+	}
+
+	return nil, fmt.Errorf("unknown state descriptor type 0x%02x", descriptor.Type)
+}
+
+// systemFee registering validator costs the same as EnrollmentTransaction
+func (descriptor *StateDescriptor) systemFee() Fixed8 {
+	if descriptor.Type != Validator || descriptor.Field != FieldRegistered {
+		return 0
+	}
+
+	for _, b := range descriptor.Value {
+		if b != 0 {
+			return SystemFees[EnrollmentTransaction]
+		}
+	}
+
+	return 0
+}
+
+type stateDescriptorJSON struct {
+	Type  json.RawMessage `json:"type"`
+	Key   string          `json:"key"`
+	Field string          `json:"field"`
+	Value string          `json:"value"`
+}
+
+// MarshalJSON implement json.Marshaler
+func (descriptor *StateDescriptor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&stateDescriptorJSON{
+		Type:  marshalName(stateTypeNames, descriptor.Type),
+		Key:   hex.EncodeToString(descriptor.Key),
+		Field: descriptor.Field,
+		Value: hex.EncodeToString(descriptor.Value),
+	})
+}
+
+// UnmarshalJSON implement json.Unmarshaler
+func (descriptor *StateDescriptor) UnmarshalJSON(data []byte) error {
+	var value stateDescriptorJSON
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	var err error
+
+	if descriptor.Type, err = unmarshalName(stateTypeNames, value.Type); err != nil {
+		return err
+	}
+
+	if descriptor.Key, err = hex.DecodeString(value.Key); err != nil {
+		return err
+	}
+
+	descriptor.Field = value.Field
+
+	descriptor.Value, err = hex.DecodeString(value.Value)
+
+	return err
+}
+
+func (descriptor *StateDescriptor) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
+
+	w.WriteU8(descriptor.Type)
+	w.WriteVarBytes(descriptor.Key)
+	w.WriteVarString(descriptor.Field)
+	w.WriteVarBytes(descriptor.Value)
+
+	return w.Err
+}
+
+func (descriptor *StateDescriptor) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
+
+	offset := r.Offset
+
+	descriptor.Type = r.ReadU8("type")
+
+	if _, ok := stateTypeNames[descriptor.Type]; r.Err == nil && !ok {
+		r.failAt(offset, "type", fmt.Errorf("unknown state descriptor type 0x%02x", descriptor.Type))
+	}
+
+	descriptor.Key = r.ReadVarBytes("key", 100)
+	descriptor.Field = r.ReadVarString("field", 32)
+	descriptor.Value = r.ReadVarBytes("value", 65535)
+
+	return r.Err
+}
+
+// StateTx state transaction for validator registration and voting
+type StateTx Transaction
+
+// NewStateTx create state transaction
+func NewStateTx(descriptors ...*StateDescriptor) *StateTx {
+	return &StateTx{
+		Type: StateTransaction,
+		Extend: &stateTx{
+			Descriptors: descriptors,
+		},
+	}
+}
+
+// NewRegisterValidatorTx create state transaction registering consensus candidate,
+// the candidate key must sign the transaction
+func NewRegisterValidatorTx(publicKey []byte) (*StateTx, error) {
+	descriptor, err := NewValidatorDescriptor(publicKey, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStateTx(descriptor), nil
+}
+
+// NewVoteTx create state transaction voting candidates from account, the account must sign the transaction
+func NewVoteTx(address string, publicKeys [][]byte) (*StateTx, error) {
+	descriptor, err := NewVoteDescriptor(address, publicKeys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStateTx(descriptor), nil
+}
+
+// Tx get basic transaction object
+func (tx *StateTx) Tx() *Transaction {
+	return (*Transaction)(tx)
+}
+
+// CalcInputs calculate inputs for outputs and the validator registration fee
+func (tx *StateTx) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO, options ...CalcOption) error {
+	base := tx.Tx()
+
+	inputs, _, err := base.selectInputs(outputs, base.systemFeeSurplus(), unspent, newCalcOptions(options))
+
+	if err != nil {
+		return err
+	}
+
+	tx.Inputs = inputs
+
+	return nil
+}
+
+type stateTx struct {
+	Descriptors []*StateDescriptor `json:"descriptors"`
+}
+
+func (tx *stateTx) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
+
+	w.WriteVarUint(uint64(len(tx.Descriptors)))
+
+	for _, descriptor := range tx.Descriptors {
+		w.WriteSerializable(descriptor)
+	}
+
+	return w.Err
+}
+
+func (tx *stateTx) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
+
+	length := r.ReadVarUint("descriptors", 16)
+
+	for i := uint64(0); i < length && r.Err == nil; i++ {
+		descriptor := &StateDescriptor{}
+
+		r.ReadSerializable(fmt.Sprintf("descriptors[%d]", i), descriptor)
+
+		tx.Descriptors = append(tx.Descriptors, descriptor)
+	}
+
+	return r.Err
+}
+
+func (tx *stateTx) scriptHashesForVerifying(resolver OutputResolver) ([][]byte, error) {
+	var hashes [][]byte
+
+	for _, descriptor := range tx.Descriptors {
+		scriptHash, err := descriptor.scriptHashForVerifying()
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes = append(hashes, scriptHash)
+	}
+
+	return hashes, nil
+}
+
+func (tx *stateTx) systemFee() Fixed8 {
+	fee := Fixed8(0)
+
+	for _, descriptor := range tx.Descriptors {
+		fee += descriptor.systemFee()
+	}
+
+	return fee
+}
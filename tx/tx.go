@@ -2,13 +2,11 @@ package tx
 
 import (
 	"bytes"
-	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"sort"
 	"strings"
 
 	"github.com/btcsuite/btcutil/base58"
@@ -34,6 +32,7 @@ const (
 	EnrollmentTransaction byte = 0x20
 	RegisterTransaction   byte = 0x40
 	ContractTransaction   byte = 0x80
+	StateTransaction      byte = 0x90
 	PublishTransaction    byte = 0xd0
 	InvocationTransaction byte = 0xd1
 )
@@ -47,7 +46,7 @@ const (
 	Vote           = byte(0x30)
 	CertURL        = byte(0x80)
 	DescriptionURL = byte(0x81)
-	Description    = byte(90)
+	Description    = byte(0x90)
 	Hash1          = byte(0xa1)
 	Hash2          = byte(0xa2)
 	Hash3          = byte(0xa3)
@@ -108,583 +107,391 @@ type Transaction struct {
 }
 
 func (tx *Transaction) String() string {
-	var buff bytes.Buffer
+	data, err := json.MarshalIndent(tx, "", "\t")
 
-	buff.WriteString("{")
-
-	switch tx.Type {
-	case MinerTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "MinerTransaction"))
-		}
-	case IssueTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "IssueTransaction"))
-		}
-	case ClaimTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "ClaimTransaction"))
-		}
-	case EnrollmentTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "EnrollmentTransaction"))
-		}
-	case RegisterTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "RegisterTransaction"))
-		}
-	case ContractTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "ContractTransaction"))
-		}
-	case PublishTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "PublishTransaction"))
-		}
-	case InvocationTransaction:
-		{
-			buff.WriteString(fmt.Sprintf("\"type\":\"%s\"", "InvocationTransaction"))
-		}
+	if err != nil {
+		return fmt.Sprintf("invalid transaction: %s", err)
 	}
 
-	buff.WriteString(fmt.Sprintf(",\"version\": %d", tx.Version))
+	return string(data)
+}
 
-	if tx.Extend != nil {
+type utxoSorter []*rpc.UTXO
 
-		if tojson, ok := tx.Extend.(ToJSON); ok {
-			buff.WriteString(fmt.Sprintf(",\"extend\": %s", tojson.JSON()))
-		} else {
-			jsondata, _ := json.Marshal(tx.Extend)
+func (s utxoSorter) Len() int      { return len(s) }
+func (s utxoSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-			buff.WriteString(fmt.Sprintf(",\"extend\": %s", jsondata))
-		}
-	}
+func (s utxoSorter) Less(i, j int) bool {
+	return s[i].Value().Cmp(s[j].Value()) < 0
+}
 
-	if tx.Attributes != nil {
-		var attrjson []string
+func filter(unspent []*rpc.UTXO, spent []*rpc.UTXO) []*rpc.UTXO {
+	result := make([]*rpc.UTXO, 0)
 
-		for _, attr := range tx.Attributes {
-			attrjson = append(attrjson, attr.JSON())
+	for _, utxo := range unspent {
+
+		for _, target := range spent {
+			if target == utxo {
+				goto Skip
+			}
 		}
 
-		buff.WriteString(fmt.Sprintf(",\"attributes\": [%s]", strings.Join(attrjson, ",")))
+		result = append(result, utxo)
+	Skip:
 	}
 
-	if tx.Inputs != nil {
-		jsondata, _ := json.Marshal(tx.Inputs)
+	return result
+}
 
-		buff.WriteString(fmt.Sprintf(",\"inputs\": %s", jsondata))
-	}
+// CalcInputs calculate tx Inputs, the utxos are chosen by the coin selector in options
+func (tx *Transaction) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO, options ...CalcOption) ([]*Vin, []*rpc.UTXO, error) {
+	return tx.selectInputs(outputs, nil, unspent, newCalcOptions(options))
+}
 
-	if tx.Outputs != nil {
-		jsondata, _ := json.Marshal(tx.Outputs)
+type assetAmount struct {
+	asset  string
+	amount Fixed8
+}
 
-		buff.WriteString(fmt.Sprintf(",\"outputs\": %s", jsondata))
-	}
+func addAssetAmount(amounts []*assetAmount, asset string, amount Fixed8) ([]*assetAmount, error) {
+	for _, target := range amounts {
+		if target.asset == asset {
+			var err error
 
-	if tx.Scripts != nil {
-		var attrjson []string
+			target.amount, err = target.amount.Add(amount)
 
-		for _, attr := range tx.Scripts {
-			attrjson = append(attrjson, attr.JSON())
+			return amounts, err
 		}
-
-		buff.WriteString(fmt.Sprintf(",\"scripts\": [%s]", strings.Join(attrjson, ",")))
 	}
 
-	if tx.SignData != nil {
-		jsondata := hex.EncodeToString(tx.SignData)
+	return append(amounts, &assetAmount{asset: asset, amount: amount}), nil
+}
 
-		buff.WriteString(fmt.Sprintf(",\"signdata\": \"%s\"", jsondata))
-	}
+// calcInputs select utxos covering the outputs plus the surplus amounts which are not paid to any output (e.g. fee)
+func (tx *Transaction) calcInputs(outputs []*Vout, surplus []*assetAmount, unspent []*rpc.UTXO, options *calcOptions) ([]*Vin, []*rpc.UTXO, error) {
 
-	if tx.SignResult != nil {
-		jsondata := hex.EncodeToString(tx.SignResult)
+	var required []*assetAmount
+	var err error
 
-		buff.WriteString(fmt.Sprintf(",\"sign\": \"%s\"", jsondata))
+	for _, vout := range outputs {
+		if required, err = addAssetAmount(required, vout.Asset, vout.Value); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	if tx.RawData != nil {
-		jsondata := hex.EncodeToString(tx.RawData)
-
-		buff.WriteString(fmt.Sprintf(",\"raw\": \"%s\"", jsondata))
+	for _, extra := range surplus {
+		if required, err = addAssetAmount(required, extra.asset, extra.amount); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	buff.WriteString(fmt.Sprintf(",\"txid\": \"%s\"", tx.TxID))
+	tx.Outputs = append(tx.Outputs, outputs...)
 
-	buff.WriteString("}")
+	inputLimit, err := tx.inputLimit(len(required), options)
 
-	var any interface{}
+	if err != nil {
+		return nil, nil, err
+	}
 
-	json.Unmarshal(buff.Bytes(), &any)
+	inputs := make([]*Vin, 0)
 
-	data, _ := json.MarshalIndent(any, "", "\t")
+	for _, target := range required {
+		if target.amount <= 0 {
+			continue
+		}
 
-	return string(data)
-}
+		var candidates []*rpc.UTXO
 
-type utxoSorter []*rpc.UTXO
+		available := Fixed8(0)
 
-func (s utxoSorter) Len() int      { return len(s) }
-func (s utxoSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+		for _, utxo := range unspent {
+			if utxo.Vout.Asset != target.asset {
+				continue
+			}
 
-func (s utxoSorter) Less(i, j int) bool {
+			candidates = append(candidates, utxo)
 
-	ival, _ := s[i].Value()
-	jval, _ := s[j].Value()
+			if available, err = available.Add(utxo.Value()); err != nil {
+				return nil, nil, err
+			}
+		}
 
-	return ival < jval
-}
+		if available < target.amount {
+			return nil, nil, &InsufficientFundsError{
+				Asset:     target.asset,
+				Required:  target.amount,
+				Available: available,
+			}
+		}
 
-func calcTxInput(amount float64, asset string, unspent []*rpc.UTXO) ([]*rpc.UTXO, float64, error) {
-	sort.Sort(utxoSorter(unspent))
+		maxInputs := 0
 
-	selected := make([]*rpc.UTXO, 0)
-	vinvalue := float64(0)
+		if inputLimit >= 0 {
+			maxInputs = inputLimit - len(inputs)
 
-	if amount == 0 {
-		return selected, vinvalue, nil
-	}
+			if maxInputs <= 0 {
+				return nil, nil, ErrInputLimit
+			}
+		}
 
-	for _, utxo := range unspent {
+		selected, err := options.selector.Select(target.amount, candidates, maxInputs)
 
-		if utxo.Vout.Asset != asset {
-			continue
+		if err != nil {
+			return nil, nil, err
 		}
 
-		var err error
-		selected = append(selected, utxo)
+		selectedAmount := Fixed8(0)
 
-		val, err := utxo.Value()
+		for _, utxo := range selected {
+			if selectedAmount, err = selectedAmount.Add(utxo.Value()); err != nil {
+				return nil, nil, err
+			}
 
-		if err != nil {
-			return nil, 0, err
+			inputs = append(inputs, &Vin{
+				Tx:      utxo.TransactionID,
+				N:       uint16(utxo.Vout.N),
+				Address: utxo.Vout.Address,
+			})
 		}
 
-		vinvalue += val
-
-		if vinvalue >= amount {
-			return selected, vinvalue, nil
+		if selectedAmount < target.amount {
+			return nil, nil, &InsufficientFundsError{
+				Asset:     target.asset,
+				Required:  target.amount,
+				Available: selectedAmount,
+			}
 		}
-	}
 
-	return selected, vinvalue, nil
-}
+		if selectedAmount > target.amount {
+			change, err := selectedAmount.Sub(target.amount)
 
-func filter(unspent []*rpc.UTXO, spent []*rpc.UTXO) []*rpc.UTXO {
-	result := make([]*rpc.UTXO, 0)
+			if err != nil {
+				return nil, nil, err
+			}
 
-	for _, utxo := range unspent {
+			address, err := options.change.ChangeAddress(target.asset, selected)
 
-		for _, target := range spent {
-			if target == utxo {
-				goto Skip
+			if err != nil {
+				return nil, nil, err
 			}
+
+			tx.Outputs = append(tx.Outputs, &Vout{
+				Asset:   target.asset,
+				Value:   change,
+				Address: address,
+			})
 		}
 
-		result = append(result, utxo)
-	Skip:
+		unspent = filter(unspent, selected)
 	}
 
-	return result
+	return inputs, unspent, nil
 }
 
-// CalcInputs calculate tx Inputs
-func (tx *Transaction) CalcInputs(outputs []*Vout, unspent []*rpc.UTXO) ([]*Vin, []*rpc.UTXO, error) {
-
-	inputs := make([]*Vin, 0)
-
-	tx.Outputs = append(tx.Outputs, outputs...)
+// inputLimit calculate how many new inputs the transaction can take, -1 means unlimited
+func (tx *Transaction) inputLimit(assets int, options *calcOptions) (int, error) {
+	limit := -1
 
-	for _, vout := range outputs {
-		amount := vout.Value.Float64()
+	if options.maxInputs > 0 {
+		limit = options.maxInputs - len(tx.Inputs)
+	}
 
-		selected, selectedAmount, err := calcTxInput(amount, vout.Asset, unspent)
+	if options.maxSize > 0 {
+		var buff bytes.Buffer
 
-		if err != nil {
-			return nil, nil, err
+		if err := tx.writeSignData(&buff); err != nil {
+			return 0, err
 		}
 
-		if selectedAmount < amount {
-			return nil, nil, ErrNoUTXO
-		}
+		// reserve one change output per asset and the growth of inputs length varint
+		base := buff.Len() + assets*vOutSize + 2
 
-		for _, utxo := range selected {
-			inputs = append(inputs, &Vin{
-				Tx: utxo.TransactionID,
-				N:  uint16(utxo.Vout.N),
-			})
-		}
+		sizeLimit := (options.maxSize - base) / vInSize
 
-		if selectedAmount > amount {
-			tx.Outputs = append(tx.Outputs, &Vout{
-				Asset:   vout.Asset,
-				Value:   MakeFixed8(selectedAmount - amount),
-				Address: selected[0].Vout.Address,
-			})
+		if limit < 0 || sizeLimit < limit {
+			limit = sizeLimit
 		}
+	}
 
-		unspent = filter(unspent, selected)
+	if limit == 0 || limit < -1 {
+		return 0, ErrInputLimit
 	}
 
-	return inputs, unspent, nil
+	return limit, nil
 }
 
 func (tx *Transaction) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
 
-	if err := tx.writeSignData(writer); err != nil {
+	if err := tx.writeSignData(w); err != nil {
 		return err
 	}
 
-	length := Varint(len(tx.Scripts))
-
-	if err := length.Write(writer); err != nil {
-		return err
-	}
+	w.WriteVarUint(uint64(len(tx.Scripts)))
 
 	for _, script := range tx.Scripts {
-		if err := script.Write(writer); err != nil {
-			return err
-		}
+		w.WriteSerializable(script)
 	}
 
-	return nil
+	return w.Err
 }
 
 func (tx *Transaction) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
 
-	header := make([]byte, 2)
-
-	_, err := reader.Read(header)
+	tx.Type = r.ReadU8("type")
+	tx.Version = r.ReadU8("version")
 
-	if err != nil {
-		return err
+	if r.Err != nil {
+		return r.Err
 	}
 
-	tx.Type = header[0]
-	tx.Version = header[1]
+	if tx.Extend == nil {
+		var err error
 
-	if tx.Extend != nil {
-		if err := tx.Extend.Read(reader); err != nil {
-			return err
+		if tx.Extend, err = newExtend(tx.Type, tx.Version); err != nil {
+			r.failAt(r.Offset-2, "type", err)
+			return r.Err
 		}
 	}
 
-	var length Varint
-
-	if err := length.Read(reader); err != nil {
-		return err
+	if tx.Extend != nil {
+		r.ReadSerializable("exclusive", tx.Extend)
 	}
 
-	for i := 0; i < int(length); i++ {
+	length := r.ReadVarUint("attributes", MaxTransactionAttributes)
+
+	for i := uint64(0); i < length && r.Err == nil; i++ {
 		attr := &Attribute{}
 
-		if err := attr.Read(reader); err != nil {
-			return err
-		}
+		r.ReadSerializable(fmt.Sprintf("attributes[%d]", i), attr)
 
 		tx.Attributes = append(tx.Attributes, attr)
 	}
 
-	if err := length.Read(reader); err != nil {
-		return err
-	}
+	length = r.ReadVarUint("inputs", MaxTransactionInputs)
 
-	for i := 0; i < int(length); i++ {
+	for i := uint64(0); i < length && r.Err == nil; i++ {
 		vin := &Vin{}
 
-		if err := vin.Read(reader); err != nil {
-			return err
-		}
+		r.ReadSerializable(fmt.Sprintf("inputs[%d]", i), vin)
 
 		tx.Inputs = append(tx.Inputs, vin)
 	}
 
-	if err := length.Read(reader); err != nil {
-		return err
-	}
+	length = r.ReadVarUint("outputs", MaxTransactionOutputs)
 
-	for i := 0; i < int(length); i++ {
+	for i := uint64(0); i < length && r.Err == nil; i++ {
 		vout := &Vout{}
 
-		if err := vout.Read(reader); err != nil {
-			return err
-		}
+		r.ReadSerializable(fmt.Sprintf("outputs[%d]", i), vout)
 
 		tx.Outputs = append(tx.Outputs, vout)
 	}
 
-	if err := length.Read(reader); err != nil {
-		return err
-	}
+	length = r.ReadVarUint("scripts", MaxTransactionWitnesses)
 
-	for i := 0; i < int(length); i++ {
+	for i := uint64(0); i < length && r.Err == nil; i++ {
 		scripts := &Scripts{}
 
-		if err := scripts.Read(reader); err != nil {
-			return err
-		}
+		r.ReadSerializable(fmt.Sprintf("scripts[%d]", i), scripts)
 
 		tx.Scripts = append(tx.Scripts, scripts)
 	}
 
-	return nil
-}
-
-// Attribute .
-type Attribute struct {
-	Usage byte
-	Data  []byte
-}
-
-// JSON .
-func (attr *Attribute) JSON() string {
-	return fmt.Sprintf("{ \"usage\":%d,\"data\": \"%s\" }", attr.Usage, hex.EncodeToString(attr.Data))
+	return r.Err
 }
 
-func (attr *Attribute) Read(reader io.Reader) error {
-
-	usage := make([]byte, 1)
-
-	_, err := reader.Read(usage)
-
-	if err != nil {
-		return err
-	}
-
-	attr.Usage = usage[0]
-
-	var body []byte
-
-	if attr.Usage == ContractHash || attr.Usage == Vote || (attr.Usage >= Hash1 && attr.Usage <= Hash15) {
-		body = make([]byte, 32)
-
-		if _, err := reader.Read(body); err != nil {
-			return err
-		}
-	} else if attr.Usage == Script {
-		body = make([]byte, 20)
-
-		if _, err := reader.Read(body); err != nil {
-			return err
-		}
-	} else if attr.Usage == ECDH02 || attr.Usage == ECDH03 {
-		body = make([]byte, 32)
-
-		if _, err := reader.Read(body); err != nil {
-			return err
-		}
-
-		body = append([]byte{attr.Usage}, body...)
-	} else if attr.Usage == DescriptionURL {
-		len := make([]byte, 1)
-
-		if _, err := reader.Read(len); err != nil {
-			return err
-		}
-
-		body = make([]byte, len[0])
-
-		if _, err := reader.Read(body); err != nil {
-			return err
-		}
-	} else if attr.Usage == Description || attr.Usage >= Remark {
-		var length Varint
-
-		if err := length.Read(reader); err != nil {
-			return err
-		}
-
-		body = make([]byte, length)
-
-		if _, err := reader.Read(body); err != nil {
-			return err
-		}
-	}
-
-	if err != nil {
-		return err
-	}
-
-	attr.Data = body
-
-	return nil
-}
-
-// Write .
-func (attr *Attribute) Write(writer io.Writer) error {
-
-	_, err := writer.Write([]byte{attr.Usage})
-
-	if err != nil {
-		return err
-	}
-
-	if attr.Usage == DescriptionURL {
-		if _, err := writer.Write([]byte{byte(len(attr.Data))}); err != nil {
-			return err
-		}
-	} else if attr.Usage == Description || attr.Usage > Remark {
-		length := Varint(len(attr.Data))
-
-		length.Write(writer)
-
-		if err != nil {
-			return err
-		}
-	}
-
-	_, err = writer.Write(attr.Data)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
+// serialized size of Vin and Vout
+const (
+	vInSize  = 32 + 2
+	vOutSize = 32 + 8 + 20
+)
 
 // Vin .
 type Vin struct {
-	Tx string `json:"tx"`
-	N  uint16 `json:"n"`
+	Tx      string
+	N       uint16
+	Address string // owner of the spent output, not serialized, filled by CalcInputs
 }
 
 func (vin *Vin) Read(reader io.Reader) error {
-	txid := make([]byte, 32)
-
-	_, err := reader.Read(txid)
-
-	if err != nil {
-		return err
-	}
+	r := NewBinReader(reader)
 
-	txid = reverseBytes(txid)
+	txid := r.ReadBytes("txid", 32)
 
-	vin.Tx = fmt.Sprintf("0x%s", hex.EncodeToString(txid))
+	vin.N = r.ReadUint16("n")
 
-	data := make([]byte, 2)
-
-	_, err = reader.Read(data)
-
-	if err != nil {
-		return err
+	if r.Err != nil {
+		return r.Err
 	}
 
-	vin.N = binary.LittleEndian.Uint16(data)
+	vin.Tx = fmt.Sprintf("0x%s", hex.EncodeToString(reverseBytes(txid)))
 
 	return nil
 }
 
 func (vin *Vin) Write(writer io.Writer) error {
-
-	data, err := hex.DecodeString(strings.TrimPrefix(vin.Tx, "0x"))
-
-	if err != nil {
-		return err
-	}
-
-	_, err = writer.Write(reverseBytes(data))
+	data, err := decodeHash(vin.Tx)
 
 	if err != nil {
 		return err
 	}
 
-	data = make([]byte, 2)
-
-	binary.LittleEndian.PutUint16(data, vin.N)
+	w := NewBinWriter(writer)
 
-	_, err = writer.Write(data)
+	w.WriteBytes(reverseBytes(data))
+	w.WriteUint16(vin.N)
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return w.Err
 }
 
 // Vout .
 type Vout struct {
-	Asset   string `json:"asset"`
-	Value   Fixed8 `json:"value"`
-	Address string `json:"address"`
+	Asset   string
+	Value   Fixed8
+	Address string
 }
 
 func (vout *Vout) Read(reader io.Reader) error {
-	assertID := make([]byte, 32)
-
-	_, err := reader.Read(assertID)
-
-	if err != nil {
-		return err
-	}
-
-	assertID = reverseBytes(assertID)
-
-	vout.Asset = fmt.Sprintf("0x%s", hex.EncodeToString(assertID))
-
-	data := make([]byte, 8)
-
-	_, err = reader.Read(data)
-
-	if err != nil {
-		return err
-	}
+	r := NewBinReader(reader)
 
-	vout.Value = Fixed8(binary.LittleEndian.Uint64(data))
+	assetID := r.ReadBytes("asset", 32)
 
-	data = make([]byte, 20)
+	vout.Value = Fixed8(r.ReadUint64("value"))
 
-	_, err = reader.Read(data)
+	scriptHash := r.ReadBytes("address", 20)
 
-	if err != nil {
-		return err
+	if r.Err != nil {
+		return r.Err
 	}
 
-	vout.Address = encodeAddress(data)
+	vout.Asset = fmt.Sprintf("0x%s", hex.EncodeToString(reverseBytes(assetID)))
+	vout.Address = encodeAddress(scriptHash)
 
 	return nil
 }
 
 func (vout *Vout) Write(writer io.Writer) error {
-
-	data, err := hex.DecodeString(strings.TrimPrefix(vout.Asset, "0x"))
-
-	if err != nil {
-		println("write err :", vout.Asset, vout.Value)
-		return err
-	}
-
-	_, err = writer.Write(reverseBytes(data))
+	assetID, err := decodeHash(vout.Asset)
 
 	if err != nil {
 		return err
 	}
 
-	value := uint64(vout.Value)
-
-	data = make([]byte, 8)
-
-	binary.LittleEndian.PutUint64(data, value)
-
-	_, err = writer.Write(data)
+	scriptHash, err := decodeAddress(vout.Address)
 
 	if err != nil {
 		return err
 	}
 
-	data, err = decodeAddress(vout.Address)
-
-	if err != nil {
-		return err
-	}
+	w := NewBinWriter(writer)
 
-	_, err = writer.Write(data)
+	w.WriteBytes(reverseBytes(assetID))
+	w.WriteUint64(uint64(vout.Value))
+	w.WriteBytes(scriptHash)
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return w.Err
 }
 
 // Scripts .
@@ -695,71 +502,43 @@ type Scripts struct {
 
 // JSON .
 func (scripts *Scripts) JSON() string {
-	return fmt.Sprintf(
-		`{"stackscript":"%s","redeemscript":"%s"}`,
-		hex.EncodeToString(scripts.StackScript),
-		hex.EncodeToString(scripts.RedeemScript))
+	data, _ := json.Marshal(scripts)
+
+	return string(data)
 }
 
 func (scripts *Scripts) Read(reader io.Reader) error {
+	r := NewBinReader(reader)
 
-	var length Varint
-
-	err := length.Read(reader)
-
-	if err != nil {
-		return err
-	}
-
-	buff := make([]byte, int(length))
-
-	_, err = reader.Read(buff)
-
-	if err != nil {
-		return err
-	}
+	scripts.StackScript = r.ReadVarBytes("invocation", MaxWitnessScriptSize)
+	scripts.RedeemScript = r.ReadVarBytes("verification", MaxWitnessScriptSize)
 
-	scripts.StackScript = buff
-
-	err = length.Read(reader)
-
-	if err != nil {
-		return err
-	}
-
-	buff = make([]byte, int(length))
-
-	_, err = reader.Read(buff)
-
-	scripts.RedeemScript = buff
-
-	return err
+	return r.Err
 }
 
 // WriteBytes .
 func (scripts *Scripts) Write(writer io.Writer) error {
+	w := NewBinWriter(writer)
 
-	length := Varint(len(scripts.StackScript))
+	w.WriteVarBytes(scripts.StackScript)
+	w.WriteVarBytes(scripts.RedeemScript)
 
-	if err := length.Write(writer); err != nil {
-		return err
-	}
+	return w.Err
+}
 
-	_, err := writer.Write(scripts.StackScript)
+// decodeHash decode 0x prefixed big endian hash string
+func decodeHash(hash string) ([]byte, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(hash, "0x"))
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	length = Varint(len(scripts.RedeemScript))
-
-	if err := length.Write(writer); err != nil {
-		return err
+	if len(data) != 32 {
+		return nil, fmt.Errorf("invalid hash %s", hash)
 	}
 
-	_, err = writer.Write(scripts.RedeemScript)
-
-	return err
+	return data, nil
 }
 
 func reverseBytes(s []byte) []byte {
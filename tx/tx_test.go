@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
-	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/inwecrypto/neogo/keystore"
 	"github.com/inwecrypto/neogo/nep5"
 	"github.com/inwecrypto/neogo/rpc"
+	"github.com/inwecrypto/neogo/script"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -206,7 +209,7 @@ func TestMintToken(t *testing.T) {
 
 	assert.NoError(t, err)
 
-	rawtx, _, err := tx.Tx().Sign(key.PrivateKey)
+	rawtx, _, err := tx.Tx().Sign(NewKeySigner(key.PrivateKey))
 
 	assert.NoError(t, err)
 
@@ -290,7 +293,7 @@ func TestTransfer(t *testing.T) {
 
 	// tx.CheckFromWitness(bytesOfFrom)
 
-	rawtx, _, err := tx.Tx().Sign(key.PrivateKey)
+	rawtx, _, err := tx.Tx().Sign(NewKeySigner(key.PrivateKey))
 
 	assert.NoError(t, err)
 
@@ -344,7 +347,7 @@ func TestTransferNEO(t *testing.T) {
 
 	assert.NoError(t, err)
 
-	rawtx, _, err := tx.Tx().Sign(from.PrivateKey)
+	rawtx, _, err := tx.Tx().Sign(NewKeySigner(from.PrivateKey))
 
 	assert.NoError(t, err)
 
@@ -406,7 +409,7 @@ func TestDoClaim(t *testing.T) {
 
 	printResult(claims)
 
-	val, err := strconv.ParseFloat(claims.Available, 64)
+	val, err := ParseFixed8(claims.Available)
 
 	assert.NoError(t, err)
 
@@ -416,7 +419,7 @@ func TestDoClaim(t *testing.T) {
 
 	assert.NoError(t, err)
 
-	rawtx, _, err := tx.Tx().Sign(key.PrivateKey)
+	rawtx, _, err := tx.Tx().Sign(NewKeySigner(key.PrivateKey))
 
 	assert.NoError(t, err)
 
@@ -454,3 +457,1527 @@ func TestFixed8(t *testing.T) {
 	println(hex.EncodeToString(fixed.Int().Bytes()))
 
 }
+
+func TestParseTransaction(t *testing.T) {
+	raw, err := hex.DecodeString("0200049b6c5fc0b78baaa797f97ea9b7fcc4c3d208dbbce02ded5ee4eebad28f00ce3a010034e594b2bb33a171de93955edc30bc812c5f43e0b2d131cd155b62c49f0c8c56000038fe6bf75c6bab7148078cd6a16c06e39f2a4098cd6a4c14066eb6d1341312f00100c8cc2d9540d701d1b3bc762a1e0b9a93d0fb022d961e17ef78fbc8319cf1b1110000000001e72d286979ee6cb1b7e65dfddfb2e384100b8d148e7758de42e4168b71792c6037ee00000000000060a7ae8b63830b00bde5f79b27331342f2616da3014140ef3b31651d90d1d9382a69a716cd540045b652dba1d9b43cdc62037c7dc58a5263f08d5d5e350afe3bebbf24a7613378736f1578cc9f51130194dedba1c36ac82321028c72ef5482e037f4795421df9c7a63fcc0e059e9314d9249e0cbf16570701bc1ac")
+
+	require.NoError(t, err)
+
+	tx, err := ParseTransaction(raw)
+
+	require.NoError(t, err)
+
+	assert.Equal(t, ClaimTransaction, tx.Type)
+	assert.Len(t, tx.Extend.(*claimTx).Inputs, 4)
+	assert.Equal(t, raw, tx.RawData)
+
+	// claimed GAS is not network fee
+	networkFee, err := tx.NetworkFee(nil)
+	require.NoError(t, err)
+	assert.Equal(t, Fixed8(0), networkFee)
+
+	pubkey, _ := hex.DecodeString("028c72ef5482e037f4795421df9c7a63fcc0e059e9314d9249e0cbf16570701bc1")
+
+	txs := []*Transaction{
+		&Transaction{Type: MinerTransaction, Extend: &minerTx{Nonce: 2083236893}},
+		&Transaction{Type: IssueTransaction},
+		&Transaction{Type: EnrollmentTransaction, Extend: &enrollmentTx{PublicKey: pubkey}},
+		&Transaction{Type: RegisterTransaction, Extend: &registerTx{
+			Name:      "test",
+			Amount:    Fixed8(100000000),
+			Precision: 8,
+			Owner:     pubkey,
+			Admin:     make([]byte, 20),
+		}},
+		&Transaction{Type: StateTransaction, Extend: &stateTx{
+			Descriptors: []*StateDescriptor{
+				&StateDescriptor{Type: 0x48, Key: pubkey, Field: "Registered", Value: []byte{0x01}},
+			},
+		}},
+		&Transaction{Type: PublishTransaction, Version: 1, Extend: &publishTx{
+			version:       1,
+			Script:        []byte{0x51, 0x66},
+			ParameterList: []byte{0x07, 0x10},
+			ReturnType:    0x05,
+			NeedStorage:   true,
+			Name:          "test",
+		}},
+		&Transaction{Type: InvocationTransaction, Version: 1, Extend: &invocationTx{Script: []byte{0x51}, Gas: Fixed8(1)}},
+	}
+
+	for _, origin := range txs {
+		var buff bytes.Buffer
+
+		require.NoError(t, origin.Write(&buff))
+
+		parsed, err := ParseTransaction(buff.Bytes())
+
+		require.NoError(t, err)
+
+		assert.Equal(t, buff.Bytes(), parsed.RawData)
+		assert.Equal(t, origin.Extend, parsed.Extend)
+	}
+
+	_, err = ParseTransaction([]byte{0x55, 0x00})
+
+	assert.Error(t, err)
+}
+
+func makeTestUTXO(n int, asset string, value string, address string) *rpc.UTXO {
+	val, _ := ParseFixed8(value)
+
+	return &rpc.UTXO{
+		TransactionID: fmt.Sprintf("0x%064x", n),
+		Vout: rpc.Vout{
+			Address: address,
+			Asset:   asset,
+			N:       n,
+			Value:   val,
+		},
+	}
+}
+
+func utxoValues(utxos []*rpc.UTXO) []string {
+	var values []string
+
+	for _, utxo := range utxos {
+		values = append(values, utxo.Value().Trimmed())
+	}
+
+	return values
+}
+
+func TestCoinSelector(t *testing.T) {
+	var unspent []*rpc.UTXO
+
+	for i, value := range []string{"0.1", "5", "1", "2", "0.5", "3"} {
+		unspent = append(unspent, makeTestUTXO(i, GasAssert, value, scriptAddress))
+	}
+
+	amount, _ := ParseFixed8("3.5")
+
+	selected, err := SmallestFirst.Select(amount, unspent, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.1", "0.5", "1", "2"}, utxoValues(selected))
+
+	_, err = SmallestFirst.Select(amount, unspent, 3)
+	assert.Equal(t, ErrInputLimit, err)
+
+	selected, err = LargestFirst.Select(amount, unspent, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5"}, utxoValues(selected))
+
+	selected, err = MinimizeInputs.Select(MakeFixed8(7.5), unspent, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5", "3"}, utxoValues(selected))
+
+	selected, err = NewBranchAndBound(nil).Select(amount, unspent, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "0.5"}, utxoValues(selected))
+
+	_, err = NewBranchAndBound(nil).Select(MakeFixed8(0.3), unspent, 0)
+	assert.Equal(t, ErrNoExactMatch, err)
+
+	selected, err = NewBranchAndBound(LargestFirst).Select(MakeFixed8(0.3), unspent, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5"}, utxoValues(selected))
+
+	selected, err = NewRandomSelector(nil).Select(amount, unspent, 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, selected)
+
+	tx := NewContractTx()
+
+	err = tx.CalcInputs([]*Vout{
+		&Vout{Asset: GasAssert, Value: amount, Address: scriptAddress},
+	}, unspent, WithSelector(NewBranchAndBound(nil)))
+
+	require.NoError(t, err)
+	assert.Len(t, tx.Inputs, 2)
+	assert.Len(t, tx.Outputs, 1)
+
+	tx = NewContractTx()
+
+	err = tx.CalcInputs([]*Vout{
+		&Vout{Asset: GasAssert, Value: MakeFixed8(12), Address: scriptAddress},
+	}, unspent)
+
+	require.Error(t, err)
+
+	insufficient, ok := err.(*InsufficientFundsError)
+
+	require.True(t, ok)
+	assert.Equal(t, GasAssert, insufficient.Asset)
+	assert.Equal(t, "0.4", insufficient.Short().Trimmed())
+
+	tx = NewContractTx()
+
+	err = tx.CalcInputs([]*Vout{
+		&Vout{Asset: GasAssert, Value: amount, Address: scriptAddress},
+	}, unspent, WithMaxSize(100))
+
+	assert.Equal(t, ErrInputLimit, err)
+	assert.Empty(t, tx.Outputs)
+
+	// failed selections leave the outputs unchanged, the retry doesn't duplicate them
+	tx = NewContractTx()
+
+	vout := []*Vout{
+		&Vout{Asset: GasAssert, Value: MakeFixed8(0.3), Address: scriptAddress},
+	}
+
+	_, err = NewBranchAndBound(nil).Select(MakeFixed8(0.3), unspent, 0)
+	require.Equal(t, ErrNoExactMatch, err)
+
+	assert.Equal(t, ErrNoExactMatch, tx.CalcInputs(vout, unspent, WithSelector(NewBranchAndBound(nil))))
+	assert.Empty(t, tx.Outputs)
+
+	require.NoError(t, tx.CalcInputs(vout, unspent, WithSelector(LargestFirst)))
+	require.Len(t, tx.Outputs, 2)
+	assert.Equal(t, MakeFixed8(0.3), tx.Outputs[0].Value)
+	assert.Equal(t, MakeFixed8(4.7), tx.Outputs[1].Value)
+
+	// 1.3 GAS including the invocation gas has no exact match
+	invocation := NewInvocationTx([]byte{0x51}, MakeFixed8(1), make([]byte, 20), []byte{0x01})
+
+	assert.Equal(t, ErrNoExactMatch, invocation.CalcInputs(vout, unspent, WithSelector(NewBranchAndBound(nil))))
+	assert.Empty(t, invocation.Outputs)
+
+	require.NoError(t, invocation.CalcInputs(vout, unspent, WithSelector(LargestFirst)))
+	require.Len(t, invocation.Outputs, 2)
+	assert.Equal(t, MakeFixed8(3.7), invocation.Outputs[1].Value)
+}
+
+func TestChangePolicy(t *testing.T) {
+	key1, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	key2, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	unspent := []*rpc.UTXO{
+		makeTestUTXO(0, NEOAssert, "1", key1.Address),
+		makeTestUTXO(1, NEOAssert, "5", key2.Address),
+	}
+
+	vout := []*Vout{
+		&Vout{Asset: NEOAssert, Value: MakeFixed8(4), Address: scriptAddress},
+	}
+
+	tx := NewContractTx()
+
+	require.NoError(t, tx.CalcInputs(vout, unspent))
+	assert.Equal(t, key1.Address, tx.Outputs[1].Address)
+	assert.Equal(t, []string{key1.Address, key2.Address}, tx.Tx().InputAddresses())
+
+	tx = NewContractTx()
+
+	require.NoError(t, tx.CalcInputs(vout, unspent, WithChange(ChangeToLargestContributor)))
+	assert.Equal(t, key2.Address, tx.Outputs[1].Address)
+
+	tx = NewContractTx()
+
+	require.NoError(t, tx.CalcInputs(vout, unspent, WithChange(ChangeToAddress(scriptAddress))))
+	assert.Equal(t, scriptAddress, tx.Outputs[1].Address)
+	assert.Equal(t, "2", tx.Outputs[1].Value.Trimmed())
+
+	tx = NewContractTx()
+
+	assert.Error(t, tx.CalcInputs(vout, unspent, WithChange(ChangeToAddress("invalid"))))
+}
+
+func TestMultiSig(t *testing.T) {
+	var keys []*keystore.Key
+	var publicKeys [][]byte
+
+	for i := 0; i < 3; i++ {
+		key, err := keystore.NewKey()
+		require.NoError(t, err)
+
+		keys = append(keys, key)
+		publicKeys = append(publicKeys, publicKeyToBytes(&key.PrivateKey.PublicKey))
+	}
+
+	redeemScript, err := CreateMultiSigRedeemScript(2, publicKeys)
+	require.NoError(t, err)
+
+	assert.Equal(t, byte(0x52), redeemScript[0])
+	assert.Equal(t, []byte{0x53, 0xae}, redeemScript[len(redeemScript)-2:])
+	assert.Len(t, redeemScript, 3+3*34)
+
+	reversed, err := CreateMultiSigRedeemScript(2, [][]byte{publicKeys[2], publicKeys[1], publicKeys[0]})
+	require.NoError(t, err)
+	assert.Equal(t, redeemScript, reversed)
+
+	_, err = CreateMultiSigRedeemScript(4, publicKeys)
+	assert.Error(t, err)
+
+	witness, err := NewMultiSigWitness(2, publicKeys)
+	require.NoError(t, err)
+
+	tx := NewContractTx()
+
+	_, _, err = tx.Tx().SignMultiSig(witness)
+	assert.Equal(t, ErrSignNotComplete, err)
+
+	for _, key := range keys[1:] {
+		signature, err := tx.Tx().SignPartial(NewKeySigner(key.PrivateKey))
+		require.NoError(t, err)
+
+		require.NoError(t, witness.AddSignature(publicKeyToBytes(&key.PrivateKey.PublicKey), signature))
+	}
+
+	other, _ := keystore.NewKey()
+
+	assert.Equal(t, ErrUnknownPublicKey, witness.AddSignature(publicKeyToBytes(&other.PrivateKey.PublicKey), make([]byte, 64)))
+
+	rawtx, txid, err := tx.Tx().SignMultiSig(witness)
+	require.NoError(t, err)
+	assert.NotEmpty(t, txid)
+
+	parsed, err := ParseTransaction(rawtx)
+	require.NoError(t, err)
+	require.Len(t, parsed.Scripts, 1)
+	assert.Equal(t, redeemScript, parsed.Scripts[0].RedeemScript)
+	assert.Len(t, parsed.Scripts[0].StackScript, 2*65)
+}
+
+type countingSigner struct {
+	Signer
+	count int
+}
+
+func (signer *countingSigner) Sign(data []byte) ([]byte, error) {
+	signer.count++
+	return signer.Signer.Sign(data)
+}
+
+func TestSigner(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	signer := &countingSigner{Signer: NewKeySigner(key.PrivateKey)}
+
+	scriptHash, err := keystore.PrivateToScriptHash(key.PrivateKey)
+	require.NoError(t, err)
+	assert.Equal(t, scriptHash, signer.ScriptHash())
+	assert.Equal(t, key.Address, encodeAddress(signer.ScriptHash()))
+
+	tx := NewContractTx()
+
+	rawtx, txid, err := tx.Tx().Sign(signer)
+	require.NoError(t, err)
+	assert.Equal(t, 1, signer.count)
+
+	parsed, err := ParseTransaction(rawtx)
+	require.NoError(t, err)
+	assert.Equal(t, txid, parsed.TxID)
+	assert.Equal(t, CreateSignatureRedeemScript(signer.PublicKey()), parsed.Scripts[0].RedeemScript)
+}
+
+func TestVerify(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	unspent := []*rpc.UTXO{
+		makeTestUTXO(0, NEOAssert, "10", key.Address),
+	}
+
+	tx := NewContractTx()
+
+	require.NoError(t, tx.CalcInputs([]*Vout{
+		&Vout{Asset: NEOAssert, Value: MakeFixed8(1), Address: scriptAddress},
+	}, unspent))
+
+	_, err = tx.Tx().Verify(nil)
+	assert.Error(t, err)
+
+	rawtx, _, err := tx.Tx().Sign(NewKeySigner(key.PrivateKey))
+	require.NoError(t, err)
+
+	results, err := tx.Tx().Verify(nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	parsed, err := ParseTransaction(rawtx)
+	require.NoError(t, err)
+
+	_, err = parsed.Verify(nil)
+	assert.Error(t, err, "parsed inputs have no owner")
+
+	_, err = parsed.Verify(UTXOResolver(unspent))
+	require.NoError(t, err)
+
+	parsed.Outputs[0].Value = MakeFixed8(2)
+
+	results, err = parsed.Verify(UTXOResolver(unspent))
+	require.Error(t, err)
+	assert.Equal(t, ErrInvalidSignature, results[0].Err)
+
+	var keys []*keystore.Key
+	var publicKeys [][]byte
+
+	for i := 0; i < 3; i++ {
+		key, _ := keystore.NewKey()
+
+		keys = append(keys, key)
+		publicKeys = append(publicKeys, publicKeyToBytes(&key.PrivateKey.PublicKey))
+	}
+
+	witness, err := NewMultiSigWitness(2, publicKeys)
+	require.NoError(t, err)
+
+	address, err := witness.Address()
+	require.NoError(t, err)
+
+	tx = NewContractTx()
+
+	require.NoError(t, tx.CalcInputs([]*Vout{
+		&Vout{Asset: NEOAssert, Value: MakeFixed8(1), Address: scriptAddress},
+	}, []*rpc.UTXO{makeTestUTXO(1, NEOAssert, "1", address)}))
+
+	for _, key := range []*keystore.Key{keys[2], keys[0]} {
+		signature, err := tx.Tx().SignPartial(NewKeySigner(key.PrivateKey))
+		require.NoError(t, err)
+		require.NoError(t, witness.AddSignature(publicKeyToBytes(&key.PrivateKey.PublicKey), signature))
+	}
+
+	_, _, err = tx.Tx().SignMultiSig(witness)
+	require.NoError(t, err)
+
+	_, err = tx.Tx().Verify(nil)
+	require.NoError(t, err)
+
+	// swap signature order
+	witness.Signatures[0], witness.Signatures[2] = witness.Signatures[2], witness.Signatures[0]
+
+	_, _, err = tx.Tx().SignMultiSig(witness)
+	require.NoError(t, err)
+
+	results, err = tx.Tx().Verify(nil)
+	require.Error(t, err)
+	assert.Equal(t, ErrInvalidSignature, results[0].Err)
+}
+
+func TestMultipleWitnesses(t *testing.T) {
+	var signers []Signer
+
+	for i := 0; i < 3; i++ {
+		key, err := keystore.NewKey()
+		require.NoError(t, err)
+
+		signers = append(signers, NewKeySigner(key.PrivateKey))
+	}
+
+	unspent := []*rpc.UTXO{
+		makeTestUTXO(0, NEOAssert, "1", encodeAddress(signers[0].ScriptHash())),
+		makeTestUTXO(1, NEOAssert, "1", encodeAddress(signers[1].ScriptHash())),
+	}
+
+	tx := NewInvocationTx([]byte{0x51}, 0, signers[2].ScriptHash(), []byte{0x01})
+
+	require.NoError(t, tx.CalcInputs([]*Vout{
+		&Vout{Asset: NEOAssert, Value: MakeFixed8(2), Address: scriptAddress},
+	}, unspent))
+
+	missing, err := tx.Tx().MissingWitnesses(nil)
+	require.NoError(t, err)
+	assert.Len(t, missing, 3)
+
+	require.NoError(t, tx.Tx().SignWith(signers[2]))
+
+	_, _, err = tx.Tx().Sign(signers[0])
+	require.NoError(t, err)
+
+	missing, err = tx.Tx().MissingWitnesses(nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{signers[1].ScriptHash()}, missing)
+
+	rawtx, _, err := tx.Tx().Sign(signers[1])
+	require.NoError(t, err)
+
+	parsed, err := ParseTransaction(rawtx)
+	require.NoError(t, err)
+	require.Len(t, parsed.Scripts, 3)
+
+	for i := 1; i < len(parsed.Scripts); i++ {
+		assert.True(t, compareScriptHash(script.Hash(parsed.Scripts[i-1].RedeemScript), script.Hash(parsed.Scripts[i].RedeemScript)) < 0)
+	}
+
+	_, err = parsed.Verify(UTXOResolver(unspent))
+	require.NoError(t, err)
+
+	// only the missing middle witness is reported, the others still verify
+	absent := script.Hash(parsed.Scripts[1].RedeemScript)
+
+	parsed.Scripts = []*Scripts{parsed.Scripts[0], parsed.Scripts[2]}
+
+	results, err := parsed.Verify(UTXOResolver(unspent))
+	require.Error(t, err)
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, -1, results[2].Index)
+	assert.Equal(t, absent, results[2].ScriptHash)
+	assert.Equal(t, ErrWitnessMissing, results[2].Err)
+
+	// unsorted witnesses
+	parsed.Scripts[0], parsed.Scripts[1] = parsed.Scripts[1], parsed.Scripts[0]
+
+	results, err = parsed.Verify(UTXOResolver(unspent))
+	require.Error(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, ErrWitnessOrder, results[1].Err)
+}
+
+func TestSizeAndFee(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	var unspent []*rpc.UTXO
+
+	for i := 0; i < 40; i++ {
+		unspent = append(unspent, makeTestUTXO(i, GasAssert, "0.1", key.Address))
+	}
+
+	unspent = append(unspent, makeTestUTXO(40, NEOAssert, "10", key.Address))
+
+	tx := NewContractTx()
+
+	require.NoError(t, tx.CalcInputs([]*Vout{
+		&Vout{Asset: NEOAssert, Value: MakeFixed8(1), Address: scriptAddress},
+	}, unspent))
+
+	estimated := tx.Tx().Size(SingleSigWitnessSize)
+
+	rawtx, _, err := tx.Tx().Sign(NewKeySigner(key.PrivateKey))
+	require.NoError(t, err)
+	assert.Equal(t, len(rawtx), estimated)
+	assert.Equal(t, len(rawtx), tx.Tx().Size())
+
+	fee, err := DefaultFeePolicy.Fee(1024)
+	require.NoError(t, err)
+	assert.Equal(t, Fixed8(0), fee)
+
+	fee, err = DefaultFeePolicy.Fee(1025)
+	require.NoError(t, err)
+	assert.Equal(t, "0.01125", fee.Trimmed())
+
+	tx = NewContractTx()
+
+	require.NoError(t, tx.CalcInputs([]*Vout{
+		&Vout{Asset: GasAssert, Value: MakeFixed8(2.55), Address: scriptAddress},
+	}, unspent, WithFeePolicy(DefaultFeePolicy), WithChange(ChangeToAddress(key.Address))))
+
+	_, _, err = tx.Tx().Sign(NewKeySigner(key.PrivateKey))
+	require.NoError(t, err)
+
+	size := tx.Tx().Size()
+	assert.True(t, size > 1024, "size %d inputs %d", size, len(tx.Inputs))
+
+	required, err := DefaultFeePolicy.Fee(size)
+	require.NoError(t, err)
+
+	networkFee, err := tx.Tx().NetworkFee(UTXOResolver(unspent))
+	require.NoError(t, err)
+	assert.True(t, networkFee >= required, "%s < %s", networkFee, required)
+
+	tx = NewContractTx()
+
+	require.NoError(t, tx.CalcInputs([]*Vout{
+		&Vout{Asset: NEOAssert, Value: MakeFixed8(1), Address: scriptAddress},
+	}, unspent, WithNetworkFee(MakeFixed8(0.15))))
+
+	networkFee, err = tx.Tx().NetworkFee(UTXOResolver(unspent))
+	require.NoError(t, err)
+	assert.Equal(t, "0.15", networkFee.Trimmed())
+
+	_, err = tx.Tx().NetworkFee(nil)
+	assert.True(t, errors.Is(err, ErrUnresolvedInput), "%v", err)
+
+	var publicKeys [][]byte
+
+	for i := 0; i < 3; i++ {
+		key, _ := keystore.NewKey()
+		publicKeys = append(publicKeys, publicKeyToBytes(&key.PrivateKey.PublicKey))
+	}
+
+	witness, err := NewMultiSigWitness(2, publicKeys)
+	require.NoError(t, err)
+
+	witness.Signatures[0] = make([]byte, 64)
+	witness.Signatures[2] = make([]byte, 64)
+
+	scripts, err := witness.Scripts()
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+
+	require.NoError(t, scripts.Write(&buff))
+	assert.Equal(t, buff.Len(), MultiSigWitnessSize(2, 3))
+}
+
+func TestTransactionJSON(t *testing.T) {
+	raw, err := hex.DecodeString("0200049b6c5fc0b78baaa797f97ea9b7fcc4c3d208dbbce02ded5ee4eebad28f00ce3a010034e594b2bb33a171de93955edc30bc812c5f43e0b2d131cd155b62c49f0c8c56000038fe6bf75c6bab7148078cd6a16c06e39f2a4098cd6a4c14066eb6d1341312f00100c8cc2d9540d701d1b3bc762a1e0b9a93d0fb022d961e17ef78fbc8319cf1b1110000000001e72d286979ee6cb1b7e65dfddfb2e384100b8d148e7758de42e4168b71792c6037ee00000000000060a7ae8b63830b00bde5f79b27331342f2616da3014140ef3b31651d90d1d9382a69a716cd540045b652dba1d9b43cdc62037c7dc58a5263f08d5d5e350afe3bebbf24a7613378736f1578cc9f51130194dedba1c36ac82321028c72ef5482e037f4795421df9c7a63fcc0e059e9314d9249e0cbf16570701bc1ac")
+
+	require.NoError(t, err)
+
+	tx, err := ParseTransaction(raw)
+
+	require.NoError(t, err)
+
+	data, err := json.Marshal(tx)
+
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	assert.Equal(t, "0x"+tx.TxID, fields["txid"])
+	assert.Equal(t, float64(len(raw)), fields["size"])
+	assert.Equal(t, "ClaimTransaction", fields["type"])
+	assert.Len(t, fields["claims"], 4)
+	assert.Equal(t, "0x3ace008fd2baeee45eed2de0bcdb08d2c3c4fcb7a97ef997a7aa8bb7c05f6c9b", fields["claims"].([]interface{})[0].(map[string]interface{})["txid"])
+	assert.Equal(t, map[string]interface{}{
+		"n":       float64(0),
+		"asset":   GasAssert,
+		"value":   "0.00060983",
+		"address": "AQawPehRTAR28xuQMXbihHBtdXFxZQtDhd",
+	}, fields["vout"].([]interface{})[0])
+	assert.Equal(t, map[string]interface{}{
+		"invocation":   "40ef3b31651d90d1d9382a69a716cd540045b652dba1d9b43cdc62037c7dc58a5263f08d5d5e350afe3bebbf24a7613378736f1578cc9f51130194dedba1c36ac8",
+		"verification": "21028c72ef5482e037f4795421df9c7a63fcc0e059e9314d9249e0cbf16570701bc1ac",
+	}, fields["scripts"].([]interface{})[0])
+
+	var parsed Transaction
+
+	require.NoError(t, json.Unmarshal(data, &parsed))
+
+	rawTx, err := parsed.RawTx()
+
+	require.NoError(t, err)
+
+	assert.Equal(t, raw, rawTx)
+
+	assert.Error(t, json.Unmarshal([]byte(strings.Replace(string(data), tx.TxID, strings.Repeat("0", 64), 1)), &parsed))
+
+	pubkey, _ := hex.DecodeString("028c72ef5482e037f4795421df9c7a63fcc0e059e9314d9249e0cbf16570701bc1")
+
+	txs := []*Transaction{
+		&Transaction{Type: MinerTransaction, Extend: &minerTx{Nonce: 2083236893}},
+		&Transaction{Type: ContractTransaction, Attributes: []*Attribute{
+			&Attribute{Usage: Script, Data: make([]byte, 20)},
+			&Attribute{Usage: Remark1, Data: []byte("hello")},
+		}},
+		&Transaction{Type: EnrollmentTransaction, Extend: &enrollmentTx{PublicKey: pubkey}},
+		&Transaction{Type: RegisterTransaction, Extend: &registerTx{
+			AssetType: Token,
+			Name:      `[{"lang":"en","name":"test"}]`,
+			Amount:    Fixed8(100000000),
+			Precision: 8,
+			Owner:     pubkey,
+			Admin:     make([]byte, 20),
+		}},
+		&Transaction{Type: RegisterTransaction, Extend: &registerTx{
+			Name:  "test",
+			Owner: []byte{0x00},
+			Admin: make([]byte, 20),
+		}},
+		&Transaction{Type: StateTransaction, Extend: &stateTx{
+			Descriptors: []*StateDescriptor{
+				&StateDescriptor{Type: Validator, Key: pubkey, Field: "Registered", Value: []byte{0x01}},
+			},
+		}},
+		&Transaction{Type: PublishTransaction, Version: 1, Extend: &publishTx{
+			version:       1,
+			Script:        []byte{0x51, 0x66},
+			ParameterList: []byte{0x07, 0x10},
+			ReturnType:    0x05,
+			NeedStorage:   true,
+			Name:          "test",
+		}},
+		&Transaction{Type: InvocationTransaction, Version: 1, Extend: &invocationTx{Script: []byte{0x51}, Gas: Fixed8(1)}},
+	}
+
+	for _, origin := range txs {
+		data, err := json.Marshal(origin)
+
+		require.NoError(t, err)
+
+		var parsed Transaction
+
+		require.NoError(t, json.Unmarshal(data, &parsed), string(data))
+
+		assert.Equal(t, origin.Extend, parsed.Extend)
+		assert.Equal(t, origin.SignData, parsed.SignData)
+	}
+}
+
+func TestPublishTx(t *testing.T) {
+	origin := &Transaction{Type: PublishTransaction, Extend: &publishTx{
+		Script:        []byte{0x00, 0xc5, 0x6b, 0x51, 0x6c, 0x76, 0x6b, 0x00, 0x52, 0x7a, 0xc4, 0x61, 0x6c, 0x75, 0x66},
+		ParameterList: []byte{0x07, 0x10},
+		ReturnType:    0x05,
+		Name:          "test",
+		CodeVersion:   "1.0",
+		Author:        "neo",
+		Email:         "neo@neo.org",
+		Description:   "version 0 contract",
+	}}
+
+	var buff bytes.Buffer
+
+	require.NoError(t, origin.Write(&buff))
+
+	parsed, err := ParseTransaction(buff.Bytes())
+
+	require.NoError(t, err)
+
+	publish := (*PublishTx)(parsed)
+
+	assert.Equal(t, buff.Bytes(), parsed.RawData)
+	assert.False(t, publish.NeedStorage())
+	assert.Equal(t, "test", publish.Name())
+	assert.Equal(t, script.Hash(publish.Script()), publish.ContractHash())
+
+	data, err := json.Marshal(parsed)
+
+	require.NoError(t, err)
+
+	var fields struct {
+		Contract struct {
+			Code map[string]interface{} `json:"code"`
+		} `json:"contract"`
+	}
+
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	assert.Equal(t, []interface{}{"String", "Array"}, fields.Contract.Code["parameters"])
+	assert.Equal(t, "ByteArray", fields.Contract.Code["returntype"])
+	assert.Equal(t, scriptHashString(publish.ContractHash()), fields.Contract.Code["hash"])
+
+	// NeedStorage exists since version 1
+	origin.Version = 1
+	origin.Extend.(*publishTx).version = 1
+
+	var buff1 bytes.Buffer
+
+	require.NoError(t, origin.Write(&buff1))
+
+	assert.Equal(t, buff.Len()+1, buff1.Len())
+
+	raw := buff1.Bytes()
+
+	raw[1] = 2
+
+	_, err = ParseTransaction(raw)
+
+	assert.Error(t, err)
+}
+
+// genesisRegisterTx register tx of the genesis block, which NewRegisterTx rejects
+func genesisRegisterTx(t *testing.T, assetType byte, names []*AssetName, precision byte, admin []byte) *RegisterTx {
+	name, err := encodeAssetNames(names)
+	require.NoError(t, err)
+
+	return &RegisterTx{
+		Type: RegisterTransaction,
+		Extend: &registerTx{
+			AssetType: assetType,
+			Name:      name,
+			Amount:    MakeFixed8(100000000),
+			Precision: precision,
+			Owner:     []byte{0x00},
+			Admin:     script.Hash(admin),
+		},
+	}
+}
+
+func TestRegisterAndIssue(t *testing.T) {
+	neo := genesisRegisterTx(t, GoverningToken, []*AssetName{
+		&AssetName{Lang: "zh-CN", Name: "小蚁股"},
+		&AssetName{Lang: "en", Name: "AntShare"},
+	}, 0, []byte{0x51})
+
+	assetID, err := neo.AssetID()
+
+	require.NoError(t, err)
+	assert.Equal(t, NEOAssert, assetID)
+
+	gas := genesisRegisterTx(t, UtilityToken, []*AssetName{
+		&AssetName{Lang: "zh-CN", Name: "小蚁币"},
+		&AssetName{Lang: "en", Name: "AntCoin"},
+	}, 8, []byte{0x00})
+
+	assetID, err = gas.AssetID()
+
+	require.NoError(t, err)
+	assert.Equal(t, GasAssert, assetID)
+
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	owner := publicKeyToBytes(&key.PrivateKey.PublicKey)
+
+	names := []*AssetName{&AssetName{Lang: "en", Name: "Test"}}
+
+	// genesis asset types and the infinity owner can't be registered
+	for _, assetType := range []byte{GoverningToken, UtilityToken} {
+		_, err = NewRegisterTx(assetType, names, MakeFixed8(1), 0, owner, key.Address)
+		assert.Error(t, err)
+	}
+
+	_, err = NewRegisterTx(Token, names, MakeFixed8(1), 0, []byte{0x00}, key.Address)
+	assert.Error(t, err)
+
+	_, err = NewRegisterTx(Token, names, MakeFixed8(1.5), 0, owner, key.Address)
+	assert.Error(t, err, "amount exceeds precision")
+
+	_, err = NewRegisterTx(Token, names, MakeFixed8(1), 9, owner, key.Address)
+	assert.Error(t, err)
+
+	_, err = NewRegisterTx(CreditFlag, names, MakeFixed8(1), 0, owner, key.Address)
+	assert.Error(t, err)
+
+	_, err = NewRegisterTx(Token, names, MakeFixed8(1), 0, owner[1:], key.Address)
+	assert.Error(t, err)
+
+	register, err := NewRegisterTx(Token, names, UnlimitedAmount, 2, owner, key.Address)
+	require.NoError(t, err)
+
+	assert.Equal(t, SystemFees[RegisterTransaction], register.Tx().SystemFee())
+
+	unspent := []*rpc.UTXO{
+		makeTestUTXO(0, GasAssert, "10000", key.Address),
+		makeTestUTXO(1, GasAssert, "1", key.Address),
+	}
+
+	require.NoError(t, register.CalcInputs(nil, unspent))
+	require.Len(t, register.Inputs, 2)
+	require.Len(t, register.Outputs, 1)
+	assert.Equal(t, "1", register.Outputs[0].Value.Trimmed())
+
+	// owner and input owner are the same account
+	_, _, err = register.Tx().Sign(NewKeySigner(key.PrivateKey))
+	require.NoError(t, err)
+
+	_, err = register.Tx().Verify(UTXOResolver(unspent))
+	require.NoError(t, err)
+
+	assetID, err = register.AssetID()
+	require.NoError(t, err)
+
+	issue, err := NewIssueTx(key.Address, []*Vout{
+		&Vout{Asset: assetID, Value: MakeFixed8(100), Address: scriptAddress},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Fixed8(0), issue.Tx().SystemFee())
+
+	issue.Version = 0
+	assert.Equal(t, SystemFees[IssueTransaction], issue.Tx().SystemFee())
+	issue.Version = 1
+
+	require.NoError(t, issue.CalcInputs(unspent))
+	assert.Len(t, issue.Inputs, 0)
+
+	hashes, err := issue.Tx().ScriptHashesForVerifying(nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{NewKeySigner(key.PrivateKey).ScriptHash()}, hashes)
+
+	_, _, err = issue.Tx().Sign(NewKeySigner(key.PrivateKey))
+	require.NoError(t, err)
+
+	_, err = issue.Tx().Verify(nil)
+	require.NoError(t, err)
+}
+
+func TestStateTx(t *testing.T) {
+	candidate, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	voter, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	candidateSigner := NewKeySigner(candidate.PrivateKey)
+	voterSigner := NewKeySigner(voter.PrivateKey)
+
+	register, err := NewRegisterValidatorTx(candidateSigner.PublicKey())
+	require.NoError(t, err)
+
+	assert.Equal(t, SystemFees[EnrollmentTransaction], register.Tx().SystemFee())
+
+	hashes, err := register.Tx().ScriptHashesForVerifying(nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{candidateSigner.ScriptHash()}, hashes)
+
+	unspent := []*rpc.UTXO{makeTestUTXO(0, GasAssert, "1000", voter.Address)}
+
+	require.NoError(t, register.CalcInputs(nil, unspent))
+	assert.Len(t, register.Inputs, 1)
+	assert.Len(t, register.Outputs, 0)
+
+	require.NoError(t, register.Tx().SignWith(candidateSigner, voterSigner))
+
+	_, err = register.Tx().Verify(UTXOResolver(unspent))
+	require.NoError(t, err)
+
+	unregister, err := NewValidatorDescriptor(candidateSigner.PublicKey(), false)
+	require.NoError(t, err)
+	assert.Equal(t, Fixed8(0), NewStateTx(unregister).Tx().SystemFee())
+
+	_, err = NewRegisterValidatorTx(candidateSigner.PublicKey()[1:])
+	assert.Error(t, err)
+
+	vote, err := NewVoteTx(voter.Address, [][]byte{candidateSigner.PublicKey(), voterSigner.PublicKey()})
+	require.NoError(t, err)
+
+	descriptor := vote.Extend.(*stateTx).Descriptors[0]
+
+	assert.Equal(t, Account, descriptor.Type)
+	assert.Equal(t, FieldVotes, descriptor.Field)
+	assert.Equal(t, voterSigner.ScriptHash(), descriptor.Key)
+	assert.Equal(t, append(append([]byte{0x02}, candidateSigner.PublicKey()...), voterSigner.PublicKey()...), descriptor.Value)
+	assert.Equal(t, Fixed8(0), vote.Tx().SystemFee())
+
+	rawtx, _, err := vote.Tx().Sign(voterSigner)
+	require.NoError(t, err)
+
+	parsed, err := ParseTransaction(rawtx)
+	require.NoError(t, err)
+
+	_, err = parsed.Verify(nil)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(parsed)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"Account"`)
+	assert.Contains(t, string(data), `"field":"Votes"`)
+
+	_, err = NewVoteTx(voter.Address, [][]byte{candidateSigner.PublicKey(), candidateSigner.PublicKey()})
+	assert.Error(t, err)
+}
+
+func TestMinerTx(t *testing.T) {
+	// genesis block miner transaction
+	raw, err := hex.DecodeString("00001dac2b7c00000000")
+	require.NoError(t, err)
+
+	parsed, err := ParseTransaction(raw)
+	require.NoError(t, err)
+
+	miner := (*MinerTx)(parsed)
+
+	assert.Equal(t, uint32(2083236893), miner.Nonce())
+	assert.Equal(t, "fb5bd72b2d6792d75dc2f1084ffa9e9f70ca85543c717a6b13d9959b452a57d6", parsed.TxID)
+
+	tx := NewMinerTx(2083236893)
+
+	rawTx, err := tx.Tx().RawTx()
+	require.NoError(t, err)
+	assert.Equal(t, raw, rawTx)
+
+	data, err := json.Marshal(tx.Tx())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"nonce":2083236893`)
+
+	var unmarshaled Transaction
+
+	require.NoError(t, json.Unmarshal(data, &unmarshaled))
+	assert.Equal(t, uint32(2083236893), (*MinerTx)(&unmarshaled).Nonce())
+}
+
+func TestBuildClaimTxs(t *testing.T) {
+	bonus, err := CalculateBonus(MakeFixed8(1), 0, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, Fixed8(8), bonus)
+
+	bonus, err = CalculateBonus(MakeFixed8(1), 1999999, 2000001, 0)
+	require.NoError(t, err)
+	assert.Equal(t, Fixed8(15), bonus)
+
+	bonus, err = CalculateBonus(MakeFixed8(100), 0, 2000000, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "16", bonus.Trimmed())
+
+	bonus, err = CalculateBonus(MakeFixed8(1), 0, 100000000, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0000001", bonus.Trimmed())
+
+	_, err = CalculateBonus(MakeFixed8(1), 10, 10, 0)
+	assert.Error(t, err)
+
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	unclaimed := &rpc.Unclaimed{}
+
+	for i := 0; i < 60; i++ {
+		utxo := makeTestUTXO(i, NEOAssert, "10", key.Address)
+
+		utxo.Block = 100
+		utxo.SpentBlock = 200
+		utxo.Gas = "0.00008"
+
+		unclaimed.Claims = append(unclaimed.Claims, utxo)
+	}
+
+	// duplicated references
+	unclaimed.Claims = append(unclaimed.Claims, unclaimed.Claims[:5]...)
+
+	references, err := ClaimReferences(unclaimed)
+	require.NoError(t, err)
+	require.Len(t, references, 65)
+
+	reference := *references[0]
+	require.NoError(t, reference.CalculateGas(0))
+	assert.Equal(t, references[0].Gas, reference.Gas)
+
+	txs, err := BuildClaimTxs(key.Address, references, 0, NewKeySigner(key.PrivateKey))
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	total := Fixed8(0)
+
+	for i, tx := range txs {
+		assert.Equal(t, []int{25, 25, 10}[i], len(tx.Extend.(*claimTx).Inputs))
+		assert.True(t, tx.Tx().Size() <= DefaultFeePolicy.FreeSize)
+
+		_, err := tx.Tx().Verify(nil)
+		require.NoError(t, err)
+
+		parsed, err := ParseTransaction(tx.RawData)
+		require.NoError(t, err)
+		assert.Equal(t, tx.TxID, parsed.TxID)
+
+		total += tx.Outputs[0].Value
+	}
+
+	assert.Equal(t, "0.0048", total.Trimmed())
+
+	// references without claimable gas are skipped
+	empty := &ClaimReference{TxID: fmt.Sprintf("0x%064x", 1000), Address: key.Address, Value: MakeFixed8(1)}
+
+	txs, err = BuildClaimTxs(key.Address, append([]*ClaimReference{empty}, references[:5]...), 0)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Len(t, txs[0].Extend.(*claimTx).Inputs, 5)
+
+	_, err = BuildClaimTxs(key.Address, []*ClaimReference{empty}, 0)
+	assert.Equal(t, ErrNoUTXO, err)
+
+	unclaimed.Claims[0].Vout.Asset = GasAssert
+
+	_, err = ClaimReferences(unclaimed)
+	assert.Error(t, err)
+
+	_, err = BuildClaimTxs(key.Address, nil, 0)
+	assert.Equal(t, ErrNoUTXO, err)
+}
+
+func TestSigningContext(t *testing.T) {
+	single, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	var keys []*keystore.Key
+	var publicKeys [][]byte
+
+	for i := 0; i < 3; i++ {
+		key, _ := keystore.NewKey()
+
+		keys = append(keys, key)
+		publicKeys = append(publicKeys, publicKeyToBytes(&key.PrivateKey.PublicKey))
+	}
+
+	redeemScript, err := CreateMultiSigRedeemScript(2, publicKeys)
+	require.NoError(t, err)
+
+	multiAddress := encodeAddress(script.Hash(redeemScript))
+
+	unspent := []*rpc.UTXO{
+		makeTestUTXO(0, NEOAssert, "1", single.Address),
+		makeTestUTXO(1, NEOAssert, "1", multiAddress),
+	}
+
+	tx := NewContractTx()
+
+	require.NoError(t, tx.CalcInputs([]*Vout{
+		&Vout{Asset: NEOAssert, Value: MakeFixed8(2), Address: scriptAddress},
+	}, unspent))
+
+	context, err := NewSigningContext(tx.Tx(), nil)
+	require.NoError(t, err)
+	require.Len(t, context.Items, 2)
+
+	require.NoError(t, context.Sign(NewKeySigner(single.PrivateKey)))
+
+	// multi-signature script is unknown yet
+	assert.Equal(t, ErrUnknownPublicKey, context.Sign(NewKeySigner(keys[0].PrivateKey)))
+
+	_, err = context.RawTx()
+	assert.Equal(t, ErrSignNotComplete, err)
+
+	require.NoError(t, context.AddScript(redeemScript))
+
+	// transfer the context to other signers
+	for _, key := range []*keystore.Key{keys[2], keys[0]} {
+		data, err := json.Marshal(context)
+		require.NoError(t, err)
+
+		var fields struct {
+			Type  string                            `json:"type"`
+			Hex   string                            `json:"hex"`
+			Items map[string]map[string]interface{} `json:"items"`
+		}
+
+		require.NoError(t, json.Unmarshal(data, &fields))
+		assert.Equal(t, "Neo.Network.P2P.Payloads.ContractTransaction", fields.Type)
+		assert.Equal(t, hex.EncodeToString(tx.SignData), fields.Hex)
+		assert.Contains(t, fields.Items, scriptHashString(script.Hash(redeemScript)))
+
+		context = &SigningContext{}
+
+		require.NoError(t, json.Unmarshal(data, context))
+		assert.False(t, context.Completed())
+
+		require.NoError(t, context.Sign(NewKeySigner(key.PrivateKey)))
+	}
+
+	assert.True(t, context.Completed())
+
+	data, err := json.Marshal(context)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "signatures")
+
+	rawtx, err := context.RawTx()
+	require.NoError(t, err)
+
+	parsed, err := ParseTransaction(rawtx)
+	require.NoError(t, err)
+
+	_, err = parsed.Verify(UTXOResolver(unspent))
+	require.NoError(t, err)
+
+	assert.Equal(t, ErrInvalidSignature, context.AddSignature(publicKeys[1], make([]byte, 64)))
+}
+
+func TestBinReader(t *testing.T) {
+	for _, value := range []uint64{0, 0xFC, 0xFD, 0xFFFF, 0x10000, 0xFFFFFFFF, 0x100000000} {
+		var buff bytes.Buffer
+
+		varint := Varint(value)
+
+		require.NoError(t, varint.Write(&buff))
+		assert.Equal(t, varintSize(int(value)), buff.Len())
+
+		var other Varint
+
+		require.NoError(t, other.Read(&buff))
+		assert.Equal(t, varint, other)
+	}
+
+	assert.Equal(t, 3, varintSize(0xFFFF))
+
+	var varint Varint
+
+	err := varint.Read(bytes.NewReader([]byte{0xFD, 0x10, 0x00}))
+	assert.True(t, errors.Is(err, ErrNonCanonicalVarint))
+
+	// short read
+	err = varint.Read(bytes.NewReader([]byte{0xFE, 0x10}))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+
+	raw, err := hex.DecodeString("00001dac2b7c00000000")
+	require.NoError(t, err)
+
+	for i := 0; i < len(raw); i++ {
+		_, err := ParseTransaction(raw[:i])
+		assert.Error(t, err)
+	}
+
+	// 17 attributes
+	_, err = ParseTransaction([]byte{0x80, 0x00, 0x11})
+	require.Error(t, err)
+
+	decodeErr, ok := err.(*DecodeError)
+	require.True(t, ok)
+	assert.Equal(t, "attributes", decodeErr.Field)
+	assert.Equal(t, int64(2), decodeErr.Offset)
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+
+	// forged witness script length must not allocate the claimed size
+	_, err = ParseTransaction([]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x01, 0xFE, 0x01, 0x00, 0x01, 0x00, 0x01})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+
+	_, err = ParseTransaction([]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xFE, 0x01, 0x00, 0x01, 0x00})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+
+	_, err = ParseTransaction([]byte{0x80, 0x00, 0x00, 0x00, 0x00, 0x01, 0xFD, 0x00, 0x10, 0x01})
+	require.Error(t, err)
+
+	decodeErr, ok = err.(*DecodeError)
+	require.True(t, ok)
+	assert.Equal(t, "scripts[0].invocation", decodeErr.Field)
+	assert.Equal(t, int64(9), decodeErr.Offset)
+
+	// truncated input of contract transaction
+	_, err = ParseTransaction([]byte{0x80, 0x00, 0x00, 0x01, 0x01, 0x02})
+	require.Error(t, err)
+
+	decodeErr, ok = err.(*DecodeError)
+	require.True(t, ok)
+	assert.Equal(t, "inputs[0].txid", decodeErr.Field)
+	assert.Equal(t, int64(4), decodeErr.Offset)
+
+	// version 0 invocation transaction has no gas
+	legacy := []byte{0xd1, 0x00, 0x01, 0x51, 0x00, 0x00, 0x00, 0x00}
+
+	tx, err := ParseTransaction(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x51}, tx.Extend.(*invocationTx).Script)
+	assert.Equal(t, legacy, tx.RawData)
+
+	_, err = ParseTransaction([]byte{0xd1, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	assert.Error(t, err, "empty invocation script")
+
+	_, err = ParseTransaction([]byte{0xd1, 0x02, 0x01, 0x51, 0x00, 0x00, 0x00, 0x00})
+	assert.Error(t, err)
+}
+
+func TestAttributes(t *testing.T) {
+	hash := bytes.Repeat([]byte{0x01}, 32)
+	scriptHash := bytes.Repeat([]byte{0x02}, 20)
+	publicKey := append([]byte{0x03}, bytes.Repeat([]byte{0x04}, 32)...)
+
+	newAttrs := []func() (*Attribute, error){
+		func() (*Attribute, error) { return NewContractHashAttribute(hash) },
+		func() (*Attribute, error) { return NewECDHAttribute(publicKey) },
+		func() (*Attribute, error) { return NewScriptAttribute(scriptHash) },
+		func() (*Attribute, error) { return NewVoteAttribute(hash) },
+		func() (*Attribute, error) { return NewDescriptionURLAttribute("https://neo.org") },
+		func() (*Attribute, error) { return NewDescriptionAttribute([]byte("description")) },
+		func() (*Attribute, error) { return NewHashAttribute(15, hash) },
+		func() (*Attribute, error) { return NewRemarkAttribute(0, []byte("remark")) },
+		func() (*Attribute, error) { return NewRemarkAttribute(15, []byte{}) },
+	}
+
+	for _, newAttr := range newAttrs {
+		attr, err := newAttr()
+		require.NoError(t, err)
+
+		var buff bytes.Buffer
+
+		require.NoError(t, attr.Write(&buff))
+
+		var decoded Attribute
+
+		require.NoError(t, decoded.Read(bytes.NewReader(buff.Bytes())))
+		assert.Equal(t, attr.Usage, decoded.Usage, attr.UsageName())
+		assert.Equal(t, attr.Data, decoded.Data, attr.UsageName())
+	}
+
+	// ECDH public key prefix is the usage and written once
+	attr, err := NewECDHAttribute(publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, ECDH03, attr.Usage)
+
+	var buff bytes.Buffer
+
+	require.NoError(t, attr.Write(&buff))
+	assert.Equal(t, publicKey, buff.Bytes())
+
+	key, ok := attr.ECDHPublicKey()
+	assert.True(t, ok)
+	assert.Equal(t, publicKey, key)
+
+	_, ok = attr.ScriptHash()
+	assert.False(t, ok)
+
+	// description is 0x90 with varint length
+	attr, err = NewDescriptionAttribute([]byte{0xAA})
+	require.NoError(t, err)
+
+	buff.Reset()
+
+	require.NoError(t, attr.Write(&buff))
+	assert.Equal(t, []byte{0x90, 0x01, 0xAA}, buff.Bytes())
+
+	attr, err = NewHashAttribute(1, hash)
+	require.NoError(t, err)
+	assert.Equal(t, Hash1, attr.Usage)
+	assert.Equal(t, `{"usage":"Hash1","data":"`+hex.EncodeToString(hash)+`"}`, attr.JSON())
+
+	attr, err = NewRemarkAttribute(0, []byte{0x01})
+	require.NoError(t, err)
+	assert.Equal(t, `{"usage":"Remark","data":"01"}`, attr.JSON())
+
+	// invalid arguments
+	_, err = NewScriptAttribute(hash)
+	assert.Error(t, err)
+
+	_, err = NewECDHAttribute(scriptHash)
+	assert.Error(t, err)
+
+	_, err = NewHashAttribute(0, hash)
+	assert.Error(t, err)
+
+	_, err = NewRemarkAttribute(16, nil)
+	assert.Error(t, err)
+
+	_, err = NewDescriptionURLAttribute(strings.Repeat("a", 256))
+	assert.Error(t, err)
+
+	_, err = NewDescriptionAttribute(make([]byte, 65536))
+	assert.Error(t, err)
+
+	// invalid lengths are rejected on write
+	assert.Error(t, (&Attribute{Usage: Vote, Data: scriptHash}).Write(&buff))
+	assert.Error(t, (&Attribute{Usage: ECDH02, Data: publicKey}).Write(&buff))
+
+	// CertUrl is not supported by the protocol
+	assert.Error(t, (&Attribute{Usage: CertURL, Data: []byte{0x01}}).Write(&buff))
+	assert.Error(t, (&Attribute{}).Read(bytes.NewReader([]byte{CertURL, 0x01, 0x01})))
+}
+
+func TestConsolidate(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	to, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	var unspent []*rpc.UTXO
+
+	for i := 0; i < 60; i++ {
+		unspent = append(unspent, makeTestUTXO(i, GasAssert, "0.1", key.Address))
+	}
+
+	unspent = append(unspent, makeTestUTXO(100, NEOAssert, "1", key.Address))
+	unspent = append(unspent, makeTestUTXO(101, "0x"+strings.Repeat("ab", 32), "5", key.Address))
+
+	// duplicated utxos
+	unspent = append(unspent, unspent[:5]...)
+
+	txs, err := Consolidate(key.Address, unspent)
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	total := Fixed8(0)
+
+	for i, tx := range txs {
+		assert.Equal(t, []int{25, 25, 10}[i], len(tx.Inputs))
+		require.Len(t, tx.Outputs, 1)
+		assert.Equal(t, GasAssert, tx.Outputs[0].Asset)
+		assert.Equal(t, key.Address, tx.Outputs[0].Address)
+
+		require.NoError(t, tx.Tx().SignWith(NewKeySigner(key.PrivateKey)))
+
+		_, err := tx.Tx().RawTx()
+		require.NoError(t, err)
+		assert.True(t, tx.Tx().Size() <= DefaultFeePolicy.FreeSize)
+
+		total += tx.Outputs[0].Value
+	}
+
+	assert.Equal(t, "6", total.Trimmed())
+
+	txs, err = Consolidate(key.Address, unspent, WithConsolidateMaxInputs(20))
+	require.NoError(t, err)
+	assert.Len(t, txs, 3)
+
+	// sweep all assets to a new address
+	txs, err = Consolidate(to.Address, unspent, WithSweepAll())
+	require.NoError(t, err)
+	require.Len(t, txs, 5)
+	assert.Equal(t, NEOAssert, txs[0].Outputs[0].Asset)
+	assert.Equal(t, "0x"+strings.Repeat("ab", 32), txs[4].Outputs[0].Asset)
+	assert.Equal(t, "5", txs[4].Outputs[0].Value.Trimmed())
+
+	// larger transactions pay network fee from the merged GAS
+	txs, err = Consolidate(key.Address, unspent, WithConsolidateFeePolicy(DefaultFeePolicy), WithConsolidateMaxSize(4096))
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	fee, err := DefaultFeePolicy.Fee(txs[0].Tx().Size(SingleSigWitnessSize))
+	require.NoError(t, err)
+	assert.True(t, fee > 0)
+	assert.Equal(t, MakeFixed8(6)-fee, txs[0].Outputs[0].Value)
+
+	// the fee budget keeps transactions free
+	txs, err = Consolidate(key.Address, unspent, WithConsolidateFeePolicy(DefaultFeePolicy), WithConsolidateMaxSize(4096), WithFeeBudget(0))
+	require.NoError(t, err)
+	assert.Len(t, txs, 3)
+
+	// dust which can't pay the fee is merged in free transactions
+	var dust []*rpc.UTXO
+
+	for i := 0; i < 60; i++ {
+		dust = append(dust, makeTestUTXO(i, GasAssert, "0.00000001", key.Address))
+	}
+
+	txs, err = Consolidate(key.Address, dust, WithConsolidateFeePolicy(DefaultFeePolicy), WithConsolidateMaxSize(4096))
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	total = Fixed8(0)
+
+	for _, tx := range txs {
+		assert.True(t, tx.Tx().Size(SingleSigWitnessSize) <= DefaultFeePolicy.FreeSize)
+
+		total += tx.Outputs[0].Value
+	}
+
+	assert.Equal(t, Fixed8(60), total)
+
+	_, err = Consolidate(key.Address, unspent, WithConsolidateMaxSize(100))
+	assert.Equal(t, ErrInputLimit, err)
+
+	_, err = Consolidate(key.Address, unspent, WithAssets("0x"+strings.Repeat("cd", 32)))
+	assert.Equal(t, ErrNoUTXO, err)
+
+	_, err = Consolidate("invalid", unspent)
+	assert.Error(t, err)
+}
+
+func TestBuilder(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	other, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	change, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	unspent := []*rpc.UTXO{
+		makeTestUTXO(0, NEOAssert, "10", key.Address),
+		makeTestUTXO(1, GasAssert, "1", key.Address),
+		makeTestUTXO(2, GasAssert, "5", other.Address),
+	}
+
+	tx, err := From(key.Address).
+		Pay(NEOAssert, other.Address, MakeFixed8(3)).
+		Remark([]byte("order 1")).
+		NetworkFee(Fixed8(100000)).
+		ChangeTo(change.Address).
+		Build(unspent)
+
+	require.NoError(t, err)
+	assert.Equal(t, ContractTransaction, tx.Type)
+	require.Len(t, tx.Inputs, 2)
+	require.Len(t, tx.Attributes, 1)
+	assert.Equal(t, Remark, tx.Attributes[0].Usage)
+
+	// payment, NEO change and GAS change
+	require.Len(t, tx.Outputs, 3)
+	assert.Equal(t, other.Address, tx.Outputs[0].Address)
+	assert.Equal(t, change.Address, tx.Outputs[1].Address)
+	assert.Equal(t, "7", tx.Outputs[1].Value.Trimmed())
+	assert.Equal(t, "0.999", tx.Outputs[2].Value.Trimmed())
+
+	require.NoError(t, tx.SignWith(NewKeySigner(key.PrivateKey)))
+
+	_, err = tx.Verify(nil)
+	require.NoError(t, err)
+
+	// utxos of other addresses are not spent
+	_, err = From(key.Address).Pay(GasAssert, other.Address, MakeFixed8(2)).Build(unspent)
+	assert.Error(t, err)
+
+	// free invocation witnesses the sender
+	tx, err = From(key.Address).Invoke([]byte{0x51}, 0).Build(unspent)
+	require.NoError(t, err)
+	assert.Equal(t, InvocationTransaction, tx.Type)
+	assert.Len(t, tx.Inputs, 0)
+
+	hashes, err := tx.ScriptHashesForVerifying(nil)
+	require.NoError(t, err)
+	require.Len(t, hashes, 1)
+
+	scriptHash, _ := DecodeAddress(key.Address)
+	assert.Equal(t, scriptHash, hashes[0])
+
+	// one builder builds independent transactions
+	builder := From(key.Address)
+
+	for i := 0; i < 5; i++ {
+		builder.Remark([]byte(fmt.Sprintf("remark %d", i)))
+	}
+
+	builder.Invoke([]byte{0x51}, 0)
+
+	first, err := builder.Build(nil)
+	require.NoError(t, err)
+
+	second, err := builder.Build(nil)
+	require.NoError(t, err)
+
+	require.Len(t, first.Attributes, 7)
+	require.Len(t, second.Attributes, 7)
+	assert.NotEqual(t, first.Attributes[6].Data, second.Attributes[6].Data)
+
+	firstHash, err := first.Hash()
+	require.NoError(t, err)
+
+	secondHash, err := second.Hash()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, firstHash, secondHash)
+
+	payer := From(key.Address).Pay(NEOAssert, other.Address, MakeFixed8(3)).Remark([]byte("pay"))
+
+	first, err = payer.Build(unspent)
+	require.NoError(t, err)
+
+	second, err = payer.Build(unspent)
+	require.NoError(t, err)
+
+	assert.Len(t, first.Outputs, 2)
+	assert.Len(t, second.Outputs, 2)
+
+	tx, err = From(key.Address).Invoke([]byte{0x51}, MakeFixed8(1)).Build(unspent)
+	require.NoError(t, err)
+	assert.Len(t, tx.Inputs, 1)
+	assert.Equal(t, MakeFixed8(1), tx.SystemFee())
+
+	// claim
+	reference := &ClaimReference{TxID: unspent[0].TransactionID, N: 0, Address: key.Address, Value: MakeFixed8(10), Gas: MakeFixed8(1)}
+
+	tx, err = From(key.Address).Claim(key.Address, reference).Build(nil)
+	require.NoError(t, err)
+	assert.Equal(t, ClaimTransaction, tx.Type)
+	assert.Equal(t, MakeFixed8(1), tx.Outputs[0].Value)
+
+	_, err = From(key.Address).Claim(key.Address, reference).Pay(NEOAssert, other.Address, MakeFixed8(1)).Build(nil)
+	assert.Equal(t, ErrBuilderConflict, err)
+
+	// references without claimable gas are skipped
+	empty := &ClaimReference{TxID: unspent[1].TransactionID, N: 1, Address: key.Address, Value: MakeFixed8(1)}
+
+	tx, err = From(key.Address).Claim(key.Address, empty, reference).Build(nil)
+	require.NoError(t, err)
+	assert.Len(t, tx.Extend.(*claimTx).Inputs, 1)
+
+	_, err = From(key.Address).Claim(key.Address, empty).Build(nil)
+	assert.Equal(t, ErrNoUTXO, err)
+
+	// the first error is kept
+	builder = From(key.Address).Pay(NEOAssert, "invalid", MakeFixed8(1)).Pay(NEOAssert, other.Address, 0)
+	assert.Error(t, builder.Err())
+	assert.Contains(t, builder.Err().Error(), "invalid pay address")
+
+	_, err = From().Pay(NEOAssert, other.Address, MakeFixed8(1)).Build(unspent)
+	assert.Equal(t, ErrBuilderNoSender, err)
+
+	_, err = From(key.Address).Build(unspent)
+	assert.Equal(t, ErrBuilderNoPayload, err)
+
+	_, err = From(key.Address).Pay("0x01", other.Address, MakeFixed8(1)).Build(unspent)
+	assert.Error(t, err)
+
+	_, err = From(key.Address).Remark(make([]byte, 65536)).Build(unspent)
+	assert.Error(t, err)
+}
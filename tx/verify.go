@@ -0,0 +1,398 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/inwecrypto/neogo/ecc"
+	"github.com/inwecrypto/neogo/rpc"
+	"github.com/inwecrypto/neogo/script"
+)
+
+// Verify errors
+var (
+	ErrWitnessMissing     = errors.New("witness missing")
+	ErrWitnessUnexpected  = errors.New("witness not required by transaction")
+	ErrWitnessOrder       = errors.New("witness not sorted by script hash")
+	ErrUnsupportedWitness = errors.New("unsupported verification script")
+	ErrInvalidSignature   = errors.New("invalid signature")
+	ErrUnresolvedInput    = errors.New("can't resolve input owner")
+)
+
+// OutputResolver find the output referenced by an input, used to get the owners of inputs
+type OutputResolver interface {
+	ResolveOutput(vin *Vin) (*Vout, error)
+}
+
+// UTXOResolver resolve outputs from rpc utxo list
+type UTXOResolver []*rpc.UTXO
+
+// ResolveOutput implement OutputResolver
+func (utxos UTXOResolver) ResolveOutput(vin *Vin) (*Vout, error) {
+	for _, utxo := range utxos {
+		if sameTxID(utxo.TransactionID, vin.Tx) && uint16(utxo.Vout.N) == vin.N {
+			return &Vout{
+				Asset:   utxo.Vout.Asset,
+				Value:   utxo.Vout.Value,
+				Address: utxo.Vout.Address,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w input %s:%d", ErrUnresolvedInput, vin.Tx, vin.N)
+}
+
+func sameTxID(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "0x"), strings.TrimPrefix(b, "0x"))
+}
+
+// inputOwner get the script hash of the address which owns vin's referenced output
+func inputOwner(vin *Vin, resolver OutputResolver) ([]byte, error) {
+	address := vin.Address
+
+	if resolver != nil {
+		vout, err := resolver.ResolveOutput(vin)
+
+		if err != nil {
+			return nil, err
+		}
+
+		address = vout.Address
+	}
+
+	if address == "" {
+		return nil, fmt.Errorf("%w input %s:%d", ErrUnresolvedInput, vin.Tx, vin.N)
+	}
+
+	return decodeAddress(address)
+}
+
+// verifyingHashes implemented by exclusive data which requires extra witnesses
+type verifyingHashes interface {
+	scriptHashesForVerifying(resolver OutputResolver) ([][]byte, error)
+}
+
+// compareScriptHash compare script hashes as neo UInt160 (little endian number)
+func compareScriptHash(a, b []byte) int {
+	for i := len(a) - 1; i >= 0; i-- {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	return 0
+}
+
+type scriptHashSorter [][]byte
+
+func (s scriptHashSorter) Len() int           { return len(s) }
+func (s scriptHashSorter) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s scriptHashSorter) Less(i, j int) bool { return compareScriptHash(s[i], s[j]) < 0 }
+
+func appendScriptHash(hashes [][]byte, scriptHash []byte) [][]byte {
+	for _, target := range hashes {
+		if bytes.Equal(target, scriptHash) {
+			return hashes
+		}
+	}
+
+	return append(hashes, scriptHash)
+}
+
+// ScriptHashesForVerifying get the script hashes which must provide witnesses, sorted as neo requires,
+// the owners of inputs come from resolver, or from Vin.Address when resolver is nil
+func (tx *Transaction) ScriptHashesForVerifying(resolver OutputResolver) ([][]byte, error) {
+	var hashes [][]byte
+
+	for _, vin := range tx.Inputs {
+		owner, err := inputOwner(vin, resolver)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes = appendScriptHash(hashes, owner)
+	}
+
+	for _, attr := range tx.Attributes {
+		if attr.Usage == Script {
+			if len(attr.Data) != 20 {
+				return nil, fmt.Errorf("invalid script attribute length %d", len(attr.Data))
+			}
+
+			hashes = appendScriptHash(hashes, attr.Data)
+		}
+	}
+
+	if extend, ok := tx.Extend.(verifyingHashes); ok {
+		extra, err := extend.scriptHashesForVerifying(resolver)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, scriptHash := range extra {
+			hashes = appendScriptHash(hashes, scriptHash)
+		}
+	}
+
+	sort.Sort(scriptHashSorter(hashes))
+
+	return hashes, nil
+}
+
+// WitnessResult verification result of one witness
+type WitnessResult struct {
+	Index      int    // index in tx.Scripts, -1 for missing witness
+	ScriptHash []byte // script hash of the verification script, or the required script hash for missing witness
+	Err        error  // nil if the witness is valid
+}
+
+func (result *WitnessResult) String() string {
+	if result.Err == nil {
+		return fmt.Sprintf("witness(%d) %s ok", result.Index, encodeAddress(result.ScriptHash))
+	}
+
+	return fmt.Sprintf("witness(%d) %s %s", result.Index, encodeAddress(result.ScriptHash), result.Err)
+}
+
+// Verify check the witnesses of the transaction offline, return one result per witness plus one result per missing witness,
+// the returned error is not nil if any witness is invalid
+func (tx *Transaction) Verify(resolver OutputResolver) ([]*WitnessResult, error) {
+	hashes, err := tx.ScriptHashesForVerifying(resolver)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buff bytes.Buffer
+
+	if err := tx.writeSignData(&buff); err != nil {
+		return nil, err
+	}
+
+	signData := buff.Bytes()
+
+	var results []*WitnessResult
+
+	var previous []byte
+
+	for i, witness := range tx.Scripts {
+		scriptHash := script.Hash(witness.RedeemScript)
+
+		// witnesses are sorted by script hash, a missing witness doesn't break the order of the rest
+		ordered := previous == nil || compareScriptHash(previous, scriptHash) < 0
+
+		previous = scriptHash
+
+		result := &WitnessResult{
+			Index:      i,
+			ScriptHash: scriptHash,
+		}
+
+		results = append(results, result)
+
+		required := false
+
+		for _, target := range hashes {
+			if bytes.Equal(target, scriptHash) {
+				required = true
+			}
+		}
+
+		if !required {
+			result.Err = ErrWitnessUnexpected
+			continue
+		}
+
+		if !ordered {
+			result.Err = ErrWitnessOrder
+			continue
+		}
+
+		result.Err = verifyWitness(witness, signData)
+	}
+
+	for _, target := range hashes {
+		found := false
+
+		for _, witness := range tx.Scripts {
+			if bytes.Equal(script.Hash(witness.RedeemScript), target) {
+				found = true
+			}
+		}
+
+		if !found {
+			results = append(results, &WitnessResult{
+				Index:      -1,
+				ScriptHash: target,
+				Err:        ErrWitnessMissing,
+			})
+		}
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			return results, fmt.Errorf("verify transaction failed, %s", result)
+		}
+	}
+
+	return results, nil
+}
+
+func verifyWitness(witness *Scripts, signData []byte) error {
+	signatures, err := parsePushOnly(witness.StackScript)
+
+	if err != nil {
+		return err
+	}
+
+	if publicKey, ok := parseSignatureRedeemScript(witness.RedeemScript); ok {
+		if len(signatures) != 1 {
+			return fmt.Errorf("expect 1 signature, got %d", len(signatures))
+		}
+
+		if !ecc.VerifySignature(publicKey, signData, signatures[0]) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	}
+
+	if m, publicKeys, ok := parseMultiSigRedeemScript(witness.RedeemScript); ok {
+		if len(signatures) != m {
+			return fmt.Errorf("expect %d signatures, got %d", m, len(signatures))
+		}
+
+		// same as neovm CHECKMULTISIG, signatures must be in public key order
+		i, j := 0, 0
+
+		for i < m && j < len(publicKeys) {
+			if ecc.VerifySignature(publicKeys[j], signData, signatures[i]) {
+				i++
+			}
+
+			j++
+
+			if m-i > len(publicKeys)-j {
+				break
+			}
+		}
+
+		if i != m {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	}
+
+	return ErrUnsupportedWitness
+}
+
+// parsePushOnly parse script which only contains push bytes ops
+func parsePushOnly(data []byte) ([][]byte, error) {
+	var result [][]byte
+
+	for offset := 0; offset < len(data); {
+		code := data[offset]
+		offset++
+
+		length := 0
+
+		switch {
+		case code >= byte(script.PUSHBYTES1) && code <= byte(script.PUSHBYTES75):
+			length = int(code)
+		case code == byte(script.PUSHDATA1) && offset+1 <= len(data):
+			length = int(data[offset])
+			offset++
+		case code == byte(script.PUSHDATA2) && offset+2 <= len(data):
+			length = int(binary.LittleEndian.Uint16(data[offset:]))
+			offset += 2
+		case code == byte(script.PUSHDATA4) && offset+4 <= len(data):
+			length = int(binary.LittleEndian.Uint32(data[offset:]))
+			offset += 4
+		default:
+			return nil, fmt.Errorf("unexpected opcode 0x%02x in invocation script", code)
+		}
+
+		if length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("invocation script push data out of range")
+		}
+
+		result = append(result, data[offset:offset+length])
+
+		offset += length
+	}
+
+	return result, nil
+}
+
+func parseSignatureRedeemScript(data []byte) ([]byte, bool) {
+	if len(data) != 35 || data[0] != 33 || data[34] != byte(script.CHECKSIG) {
+		return nil, false
+	}
+
+	return data[1:34], true
+}
+
+func parseMultiSigRedeemScript(data []byte) (int, [][]byte, bool) {
+	if len(data) < 37 || data[len(data)-1] != byte(script.CHECKMULTISIG) {
+		return 0, nil, false
+	}
+
+	m, offset, ok := parsePushInt(data, 0)
+
+	if !ok {
+		return 0, nil, false
+	}
+
+	var publicKeys [][]byte
+
+	for offset < len(data) && data[offset] == 33 {
+		if offset+34 > len(data) {
+			return 0, nil, false
+		}
+
+		publicKeys = append(publicKeys, data[offset+1:offset+34])
+
+		offset += 34
+	}
+
+	n, offset, ok := parsePushInt(data, offset)
+
+	if !ok || offset != len(data)-1 || n != len(publicKeys) || m < 1 || m > n {
+		return 0, nil, false
+	}
+
+	return m, publicKeys, true
+}
+
+// parsePushInt parse PUSH1-PUSH16 or small integer push bytes
+func parsePushInt(data []byte, offset int) (int, int, bool) {
+	if offset >= len(data) {
+		return 0, offset, false
+	}
+
+	code := data[offset]
+
+	if code >= byte(script.PUSH1) && code <= byte(script.PUSH16) {
+		return int(code) - int(script.PUSH1) + 1, offset + 1, true
+	}
+
+	if code >= 1 && code <= 2 && offset+1+int(code) <= len(data) {
+		value := new(big.Int).SetBytes(reverseBytes(append([]byte{}, data[offset+1:offset+1+int(code)]...)))
+
+		return int(value.Int64()), offset + 1 + int(code), true
+	}
+
+	return 0, offset, false
+}
@@ -0,0 +1,1524 @@
+package vm
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/inwecrypto/neogo/ecc"
+	"github.com/inwecrypto/neogo/script"
+)
+
+// Execution limits of neo 2.x
+const (
+	MaxStackSize           = 2 * 1024
+	MaxItemSize            = 1024 * 1024
+	MaxArraySize           = 1024
+	MaxInvocationStackSize = 1024
+	MaxShift               = 256
+)
+
+// GasRatio Fixed8 amount of one gas unit, most instructions cost one unit (0.001 GAS)
+const GasRatio = 100000
+
+// Engine errors
+var (
+	ErrGasLimit          = errors.New("gas limit exceeded")
+	ErrInvalidOpCode     = errors.New("invalid opcode")
+	ErrThrow             = errors.New("script throws")
+	ErrInvalidJump       = errors.New("jump target out of script")
+	ErrInvalidType       = errors.New("invalid stack item type")
+	ErrLimit             = errors.New("execution limit exceeded")
+	ErrNoSysCallHandler  = errors.New("syscall handler not set")
+	ErrNoScriptTable     = errors.New("script table not set")
+	ErrDivideByZero      = errors.New("divide by zero")
+	ErrInvalidArgument   = errors.New("invalid argument")
+	ErrInvalidScriptHash = errors.New("invalid script hash")
+)
+
+// State engine state
+type State byte
+
+// Engine states
+const (
+	None  State = 0
+	Halt  State = 1
+	Fault State = 2
+	Break State = 4
+)
+
+var stateNames = map[State]string{
+	None:  "NONE",
+	Halt:  "HALT",
+	Fault: "FAULT",
+	Break: "BREAK",
+}
+
+func (state State) String() string {
+	if name, ok := stateNames[state]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%02x", byte(state))
+}
+
+// Context execution context of one script in the invocation stack
+type Context struct {
+	Script          []byte // script code
+	IP              int    // instruction pointer, the offset of the next instruction
+	returnCount     int    // return value count required by CALL_I and CALL_E ops, -1 is not checked
+	evaluationStack *Stack // own stacks of CALL_I and CALL_E contexts, shared with the caller otherwise
+	altStack        *Stack
+}
+
+// ScriptHash get script hash of context
+func (context *Context) ScriptHash() []byte {
+	return script.Hash(context.Script)
+}
+
+// SysCallHandler handle SYSCALL api, returned error faults the engine
+type SysCallHandler func(engine *Engine, api string) error
+
+// ScriptTable resolve contract script of APPCALL and TAILCALL
+type ScriptTable interface {
+	GetScript(scriptHash []byte) ([]byte, error)
+}
+
+// EngineOption NewEngine option
+type EngineOption func(engine *Engine)
+
+// WithMessage set script container sign data checked by CHECKSIG and CHECKMULTISIG
+func WithMessage(message []byte) EngineOption {
+	return func(engine *Engine) {
+		engine.Message = message
+	}
+}
+
+// WithGasLimit fault when consumed gas exceeds limit, limit is Fixed8 amount, 0 means unlimited
+func WithGasLimit(limit int64) EngineOption {
+	return func(engine *Engine) {
+		engine.GasLimit = limit
+	}
+}
+
+// WithSysCall set SYSCALL handler
+func WithSysCall(handler SysCallHandler) EngineOption {
+	return func(engine *Engine) {
+		engine.sysCall = handler
+	}
+}
+
+// WithScriptTable set contract scripts of APPCALL and TAILCALL
+func WithScriptTable(table ScriptTable) EngineOption {
+	return func(engine *Engine) {
+		engine.scripts = table
+	}
+}
+
+// Engine NeoVM 2.x execution engine
+type Engine struct {
+	InvocationStack []*Context // the last context is executing
+	EvaluationStack *Stack
+	AltStack        *Stack
+	State           State
+	GasConsumed     int64  // consumed gas, Fixed8 amount
+	GasLimit        int64  // Fixed8 amount, 0 means unlimited
+	Message         []byte // script container sign data
+	Err             error  // fault reason
+	sysCall         SysCallHandler
+	scripts         ScriptTable
+}
+
+// NewEngine create execution engine
+func NewEngine(options ...EngineOption) *Engine {
+	engine := &Engine{
+		EvaluationStack: NewStack(),
+		AltStack:        NewStack(),
+	}
+
+	for _, option := range options {
+		option(engine)
+	}
+
+	return engine
+}
+
+// Run load script and execute it
+func Run(code []byte, options ...EngineOption) *Engine {
+	engine := NewEngine(options...)
+
+	if err := engine.LoadScript(code); err != nil {
+		engine.fault(err)
+
+		return engine
+	}
+
+	engine.Execute()
+
+	return engine
+}
+
+// LoadScript push script into the invocation stack
+func (engine *Engine) LoadScript(code []byte) error {
+	if len(engine.InvocationStack) >= MaxInvocationStackSize {
+		return fmt.Errorf("%w: invocation stack", ErrLimit)
+	}
+
+	engine.InvocationStack = append(engine.InvocationStack, &Context{
+		Script:          code,
+		returnCount:     -1,
+		evaluationStack: engine.EvaluationStack,
+		altStack:        engine.AltStack,
+	})
+
+	return nil
+}
+
+// CurrentContext get executing context
+func (engine *Engine) CurrentContext() *Context {
+	if len(engine.InvocationStack) == 0 {
+		return nil
+	}
+
+	return engine.InvocationStack[len(engine.InvocationStack)-1]
+}
+
+// CallingContext get the context which called the current one
+func (engine *Engine) CallingContext() *Context {
+	if len(engine.InvocationStack) < 2 {
+		return nil
+	}
+
+	return engine.InvocationStack[len(engine.InvocationStack)-2]
+}
+
+// EntryContext get the first loaded context
+func (engine *Engine) EntryContext() *Context {
+	if len(engine.InvocationStack) == 0 {
+		return nil
+	}
+
+	return engine.InvocationStack[0]
+}
+
+// AddGas consume gas units, the engine faults when the limit is exceeded
+func (engine *Engine) AddGas(units int64) error {
+	engine.GasConsumed += units * GasRatio
+
+	if engine.GasLimit > 0 && engine.GasConsumed > engine.GasLimit {
+		return ErrGasLimit
+	}
+
+	return nil
+}
+
+// Execute run until HALT or FAULT
+func (engine *Engine) Execute() State {
+	engine.State &^= Break
+
+	for engine.State&(Halt|Fault|Break) == 0 {
+		engine.StepInto()
+	}
+
+	return engine.State
+}
+
+// StepInto execute one instruction
+func (engine *Engine) StepInto() {
+	if engine.State&(Halt|Fault) != 0 {
+		return
+	}
+
+	context := engine.CurrentContext()
+
+	if context == nil {
+		engine.State |= Halt
+
+		return
+	}
+
+	offset := context.IP
+
+	// running off the end of the script returns
+	op := &script.Op{Code: script.RET}
+
+	if offset < len(context.Script) {
+		var err error
+
+		if op, err = script.ParseOp(context.Script, offset); err != nil {
+			engine.fault(err)
+
+			return
+		}
+	}
+
+	if err := engine.AddGas(engine.price(op)); err != nil {
+		engine.fault(fmt.Errorf("[%04x] %s: %w", offset, op.Code, err))
+
+		return
+	}
+
+	context.IP = offset + op.Size()
+
+	if err := engine.execute(context, offset, op); err != nil {
+		engine.fault(fmt.Errorf("[%04x] %s: %w", offset, op.Code, err))
+
+		return
+	}
+
+	if engine.stackSize() > MaxStackSize {
+		engine.fault(fmt.Errorf("[%04x] %s: %w: stack size", offset, op.Code, ErrLimit))
+	}
+}
+
+// stackSize get item count of the stacks of all contexts
+func (engine *Engine) stackSize() int {
+	size := engine.EvaluationStack.Count() + engine.AltStack.Count()
+
+	var last *Stack
+
+	// contexts sharing stacks are adjacent
+	for _, context := range engine.InvocationStack {
+		if context.evaluationStack != last && context.evaluationStack != engine.EvaluationStack {
+			size += context.evaluationStack.Count() + context.altStack.Count()
+		}
+
+		last = context.evaluationStack
+	}
+
+	return size
+}
+
+func (engine *Engine) fault(err error) {
+	engine.State |= Fault
+	engine.Err = err
+}
+
+// price gas units of instruction from the opcode table
+func (engine *Engine) price(op *script.Op) int64 {
+	info, ok := script.LookupOp(op.Code)
+
+	if !ok {
+		return 1
+	}
+
+	if op.Code == script.CHECKMULTISIG {
+		item, err := engine.EvaluationStack.Peek(0)
+
+		if err != nil {
+			return 1
+		}
+
+		n := 0
+
+		if array, ok := item.(*Array); ok {
+			n = len(array.Items)
+		} else if value, err := item.BigInt(); err == nil && value.IsInt64() {
+			n = int(value.Int64())
+		}
+
+		if n < 1 {
+			return 1
+		}
+
+		return info.Price * int64(n)
+	}
+
+	return info.Price
+}
+
+func (engine *Engine) push(item StackItem) {
+	engine.EvaluationStack.Push(item)
+}
+
+func (engine *Engine) pushBytes(data []byte) error {
+	if len(data) > MaxItemSize {
+		return fmt.Errorf("%w: item size", ErrLimit)
+	}
+
+	engine.push(ByteArray(data))
+
+	return nil
+}
+
+func (engine *Engine) pushInt(value *big.Int) error {
+	if len(IntToBytes(value)) > MaxIntegerSize {
+		return ErrIntegerSize
+	}
+
+	engine.push(&Integer{value: value})
+
+	return nil
+}
+
+func (engine *Engine) pushBool(value bool) {
+	engine.push(Boolean(value))
+}
+
+func (engine *Engine) pop() (StackItem, error) {
+	return engine.EvaluationStack.Pop()
+}
+
+func (engine *Engine) popBytes() ([]byte, error) {
+	item, err := engine.pop()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Bytes()
+}
+
+func (engine *Engine) popInt() (*big.Int, error) {
+	item, err := engine.pop()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return item.BigInt()
+}
+
+// popIndex pop integer in the range of int
+func (engine *Engine) popIndex() (int, error) {
+	value, err := engine.popInt()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !value.IsInt64() || value.Int64() > MaxItemSize || value.Int64() < -MaxItemSize {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidArgument, value)
+	}
+
+	return int(value.Int64()), nil
+}
+
+func (engine *Engine) popBool() (bool, error) {
+	item, err := engine.pop()
+
+	if err != nil {
+		return false, err
+	}
+
+	return item.Bool(), nil
+}
+
+func (engine *Engine) execute(context *Context, offset int, op *script.Op) error {
+	code := op.Code
+
+	switch {
+	case code == script.PUSH0:
+		engine.push(ByteArray{})
+
+		return nil
+	case code >= script.PUSHBYTES1 && code <= script.PUSHDATA4:
+		data, _ := op.Data()
+
+		return engine.pushBytes(append([]byte{}, data...))
+	case code == script.PUSHM1 || (code >= script.PUSH1 && code <= script.PUSH16):
+		return engine.pushInt(big.NewInt(int64(code) - int64(script.PUSH1) + 1))
+	case code >= script.NOP && code <= script.TAILCALL:
+		return engine.executeFlow(context, offset, op)
+	case code >= script.CALL_I && code <= script.CALL_EDT:
+		return engine.executeCall(context, offset, op)
+	case code >= script.DUPFROMALTSTACK && code <= script.TUCK:
+		return engine.executeStack(code)
+	case code >= script.CAT && code <= script.SIZE:
+		return engine.executeSplice(code)
+	case code >= script.INVERT && code <= script.WITHIN:
+		return engine.executeArithmetic(code)
+	case code >= script.SHA1 && code <= script.CHECKMULTISIG:
+		return engine.executeCrypto(code)
+	case code >= script.ARRAYSIZE && code <= script.VALUES:
+		return engine.executeArray(code)
+	case code == script.THROW:
+		return ErrThrow
+	case code == script.THROWIFNOT:
+		value, err := engine.popBool()
+
+		if err != nil {
+			return err
+		}
+
+		if !value {
+			return ErrThrow
+		}
+
+		return nil
+	}
+
+	return ErrInvalidOpCode
+}
+
+func (engine *Engine) executeFlow(context *Context, offset int, op *script.Op) error {
+	switch op.Code {
+	case script.NOP:
+		return nil
+	case script.JMP, script.JMPIF, script.JMPIFNOT, script.CALL:
+		jump, _ := op.JumpOffset()
+
+		target := offset + int(jump)
+
+		if target < 0 || target > len(context.Script) {
+			return ErrInvalidJump
+		}
+
+		switch op.Code {
+		case script.JMPIF, script.JMPIFNOT:
+			value, err := engine.popBool()
+
+			if err != nil {
+				return err
+			}
+
+			if value != (op.Code == script.JMPIF) {
+				return nil
+			}
+		case script.CALL:
+			// the caller continues after the CALL, the callee is a copy of the context at the target
+			if err := engine.LoadScript(context.Script); err != nil {
+				return err
+			}
+
+			context = engine.CurrentContext()
+		}
+
+		context.IP = target
+
+		return nil
+	case script.RET:
+		if context.returnCount >= 0 && context.evaluationStack.Count() != context.returnCount {
+			return fmt.Errorf("%w: expect %d return values", ErrInvalidArgument, context.returnCount)
+		}
+
+		engine.InvocationStack = engine.InvocationStack[:len(engine.InvocationStack)-1]
+
+		caller := engine.CurrentContext()
+
+		if caller == nil {
+			engine.State |= Halt
+
+			return nil
+		}
+
+		// return values of own stacks are moved to the caller
+		if caller.evaluationStack != context.evaluationStack {
+			if err := context.evaluationStack.moveTo(caller.evaluationStack, context.evaluationStack.Count()); err != nil {
+				return err
+			}
+
+			engine.EvaluationStack = caller.evaluationStack
+			engine.AltStack = caller.altStack
+		}
+
+		return nil
+	case script.APPCALL, script.TAILCALL:
+		scriptHash, _ := op.ScriptHash()
+
+		// zero hash is dynamic call, the hash is taken from the stack
+		if bytes.Equal(scriptHash, make([]byte, 20)) {
+			var err error
+
+			if scriptHash, err = engine.popBytes(); err != nil {
+				return err
+			}
+
+			if len(scriptHash) != 20 {
+				return ErrInvalidScriptHash
+			}
+		}
+
+		return engine.loadContract(scriptHash, op.Code == script.TAILCALL)
+	case script.SYSCALL:
+		api, _ := op.SysCall()
+
+		if engine.sysCall == nil {
+			return ErrNoSysCallHandler
+		}
+
+		return engine.sysCall(engine, api)
+	}
+
+	return ErrInvalidOpCode
+}
+
+// loadContract load contract script from the script table, tail call replaces the current context
+func (engine *Engine) loadContract(scriptHash []byte, tailCall bool) error {
+	if engine.scripts == nil {
+		return ErrNoScriptTable
+	}
+
+	code, err := engine.scripts.GetScript(scriptHash)
+
+	if err != nil {
+		return err
+	}
+
+	returnCount := -1
+
+	// the tail called context returns in place of the current one
+	if tailCall {
+		returnCount = engine.CurrentContext().returnCount
+
+		engine.InvocationStack = engine.InvocationStack[:len(engine.InvocationStack)-1]
+	}
+
+	if err := engine.LoadScript(code); err != nil {
+		return err
+	}
+
+	engine.CurrentContext().returnCount = returnCount
+
+	return nil
+}
+
+// executeCall CALL_I and CALL_E ops, the called context gets own stacks holding its pcount parameters
+// and must return exactly rvcount items
+func (engine *Engine) executeCall(context *Context, offset int, op *script.Op) error {
+	rvcount, pcount, _ := op.CallCounts()
+
+	switch op.Code {
+	case script.CALL_I:
+		jump, _ := op.JumpOffset()
+
+		target := offset + int(jump)
+
+		if target < 0 || target > len(context.Script) {
+			return ErrInvalidJump
+		}
+
+		if engine.EvaluationStack.Count() < pcount {
+			return ErrStackUnderflow
+		}
+
+		if err := engine.LoadScript(context.Script); err != nil {
+			return err
+		}
+
+		engine.CurrentContext().IP = target
+
+		return engine.loadStacks(rvcount, pcount)
+	}
+
+	scriptHash, ok := op.ScriptHash()
+
+	// CALL_ED and CALL_EDT take the script hash from the stack
+	if !ok {
+		var err error
+
+		if scriptHash, err = engine.popBytes(); err != nil {
+			return err
+		}
+
+		if len(scriptHash) != 20 {
+			return ErrInvalidScriptHash
+		}
+	}
+
+	if engine.EvaluationStack.Count() < pcount {
+		return ErrStackUnderflow
+	}
+
+	tailCall := op.Code == script.CALL_ET || op.Code == script.CALL_EDT
+
+	if tailCall && context.returnCount != rvcount {
+		return fmt.Errorf("%w: tail call return count %d, expect %d", ErrInvalidArgument, rvcount, context.returnCount)
+	}
+
+	if err := engine.loadContract(scriptHash, tailCall); err != nil {
+		return err
+	}
+
+	return engine.loadStacks(rvcount, pcount)
+}
+
+// loadStacks move pcount parameters to new stacks of the current context
+func (engine *Engine) loadStacks(rvcount, pcount int) error {
+	called := engine.CurrentContext()
+
+	called.returnCount = rvcount
+	called.evaluationStack = NewStack()
+	called.altStack = NewStack()
+
+	if err := engine.EvaluationStack.moveTo(called.evaluationStack, pcount); err != nil {
+		return err
+	}
+
+	engine.EvaluationStack = called.evaluationStack
+	engine.AltStack = called.altStack
+
+	return nil
+}
+
+func (engine *Engine) executeStack(code script.OpCode) error {
+	stack := engine.EvaluationStack
+
+	switch code {
+	case script.DUPFROMALTSTACK:
+		item, err := engine.AltStack.Peek(0)
+
+		if err != nil {
+			return err
+		}
+
+		engine.push(item)
+	case script.TOALTSTACK:
+		item, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		engine.AltStack.Push(item)
+	case script.FROMALTSTACK:
+		item, err := engine.AltStack.Pop()
+
+		if err != nil {
+			return err
+		}
+
+		engine.push(item)
+	case script.XDROP, script.XSWAP, script.XTUCK, script.PICK, script.ROLL:
+		n, err := engine.popIndex()
+
+		if err != nil {
+			return err
+		}
+
+		if n < 0 || (n == 0 && code == script.XTUCK) {
+			return fmt.Errorf("%w: %d", ErrInvalidArgument, n)
+		}
+
+		switch code {
+		case script.XDROP:
+			_, err = stack.Remove(n)
+		case script.XSWAP:
+			var a, b StackItem
+
+			if a, err = stack.Peek(0); err != nil {
+				return err
+			}
+
+			if b, err = stack.Peek(n); err != nil {
+				return err
+			}
+
+			stack.Set(0, b)
+			stack.Set(n, a)
+		case script.XTUCK:
+			var item StackItem
+
+			if item, err = stack.Peek(0); err != nil {
+				return err
+			}
+
+			err = stack.Insert(n, item)
+		case script.PICK:
+			var item StackItem
+
+			if item, err = stack.Peek(n); err != nil {
+				return err
+			}
+
+			engine.push(item)
+		case script.ROLL:
+			var item StackItem
+
+			if item, err = stack.Remove(n); err != nil {
+				return err
+			}
+
+			engine.push(item)
+		}
+
+		return err
+	case script.DEPTH:
+		return engine.pushInt(big.NewInt(int64(stack.Count())))
+	case script.DROP:
+		_, err := engine.pop()
+
+		return err
+	case script.DUP, script.OVER:
+		n := 0
+
+		if code == script.OVER {
+			n = 1
+		}
+
+		item, err := stack.Peek(n)
+
+		if err != nil {
+			return err
+		}
+
+		engine.push(item)
+	case script.NIP:
+		_, err := stack.Remove(1)
+
+		return err
+	case script.ROT, script.SWAP:
+		n := 2
+
+		if code == script.SWAP {
+			n = 1
+		}
+
+		item, err := stack.Remove(n)
+
+		if err != nil {
+			return err
+		}
+
+		engine.push(item)
+	case script.TUCK:
+		item, err := stack.Peek(0)
+
+		if err != nil {
+			return err
+		}
+
+		return stack.Insert(2, item)
+	default:
+		return ErrInvalidOpCode
+	}
+
+	return nil
+}
+
+func (engine *Engine) executeSplice(code script.OpCode) error {
+	switch code {
+	case script.CAT:
+		second, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		first, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		return engine.pushBytes(append(append([]byte{}, first...), second...))
+	case script.SUBSTR:
+		count, err := engine.popIndex()
+
+		if err != nil {
+			return err
+		}
+
+		index, err := engine.popIndex()
+
+		if err != nil {
+			return err
+		}
+
+		data, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		if count < 0 || index < 0 {
+			return ErrInvalidArgument
+		}
+
+		if index > len(data) {
+			index = len(data)
+		}
+
+		if index+count > len(data) {
+			count = len(data) - index
+		}
+
+		return engine.pushBytes(append([]byte{}, data[index:index+count]...))
+	case script.LEFT, script.RIGHT:
+		count, err := engine.popIndex()
+
+		if err != nil {
+			return err
+		}
+
+		data, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		if count < 0 {
+			return ErrInvalidArgument
+		}
+
+		if code == script.LEFT {
+			if count > len(data) {
+				count = len(data)
+			}
+
+			return engine.pushBytes(append([]byte{}, data[:count]...))
+		}
+
+		if count > len(data) {
+			return ErrInvalidArgument
+		}
+
+		return engine.pushBytes(append([]byte{}, data[len(data)-count:]...))
+	case script.SIZE:
+		data, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		return engine.pushInt(big.NewInt(int64(len(data))))
+	}
+
+	return ErrInvalidOpCode
+}
+
+func (engine *Engine) executeArithmetic(code script.OpCode) error {
+	switch code {
+	case script.EQUAL:
+		b, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		a, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		engine.pushBool(a.Equals(b))
+
+		return nil
+	case script.NOT:
+		value, err := engine.popBool()
+
+		if err != nil {
+			return err
+		}
+
+		engine.pushBool(!value)
+
+		return nil
+	case script.BOOLAND, script.BOOLOR:
+		b, err := engine.popBool()
+
+		if err != nil {
+			return err
+		}
+
+		a, err := engine.popBool()
+
+		if err != nil {
+			return err
+		}
+
+		if code == script.BOOLAND {
+			engine.pushBool(a && b)
+		} else {
+			engine.pushBool(a || b)
+		}
+
+		return nil
+	case script.INVERT, script.INC, script.DEC, script.SIGN, script.NEGATE, script.ABS, script.NZ:
+		x, err := engine.popInt()
+
+		if err != nil {
+			return err
+		}
+
+		return engine.unary(code, x)
+	case script.WITHIN:
+		b, err := engine.popInt()
+
+		if err != nil {
+			return err
+		}
+
+		a, err := engine.popInt()
+
+		if err != nil {
+			return err
+		}
+
+		x, err := engine.popInt()
+
+		if err != nil {
+			return err
+		}
+
+		engine.pushBool(a.Cmp(x) <= 0 && x.Cmp(b) < 0)
+
+		return nil
+	}
+
+	b, err := engine.popInt()
+
+	if err != nil {
+		return err
+	}
+
+	a, err := engine.popInt()
+
+	if err != nil {
+		return err
+	}
+
+	return engine.binary(code, a, b)
+}
+
+func (engine *Engine) unary(code script.OpCode, x *big.Int) error {
+	result := new(big.Int)
+
+	switch code {
+	case script.INVERT:
+		result.Not(x)
+	case script.INC:
+		result.Add(x, big.NewInt(1))
+	case script.DEC:
+		result.Sub(x, big.NewInt(1))
+	case script.SIGN:
+		result.SetInt64(int64(x.Sign()))
+	case script.NEGATE:
+		result.Neg(x)
+	case script.ABS:
+		result.Abs(x)
+	case script.NZ:
+		engine.pushBool(x.Sign() != 0)
+
+		return nil
+	default:
+		return ErrInvalidOpCode
+	}
+
+	return engine.pushInt(result)
+}
+
+func (engine *Engine) binary(code script.OpCode, a *big.Int, b *big.Int) error {
+	result := new(big.Int)
+
+	switch code {
+	case script.AND:
+		result.And(a, b)
+	case script.OR:
+		result.Or(a, b)
+	case script.XOR:
+		result.Xor(a, b)
+	case script.ADD:
+		result.Add(a, b)
+	case script.SUB:
+		result.Sub(a, b)
+	case script.MUL:
+		result.Mul(a, b)
+	case script.DIV, script.MOD:
+		if b.Sign() == 0 {
+			return ErrDivideByZero
+		}
+
+		// truncated division as C# BigInteger
+		if code == script.DIV {
+			result.Quo(a, b)
+		} else {
+			result.Rem(a, b)
+		}
+	case script.SHL, script.SHR:
+		if !b.IsInt64() || b.Int64() < -MaxShift || b.Int64() > MaxShift {
+			return fmt.Errorf("%w: shift %s", ErrInvalidArgument, b)
+		}
+
+		shift := b.Int64()
+
+		// a negative shift shifts the other way as C# BigInteger
+		if code == script.SHR {
+			shift = -shift
+		}
+
+		if shift >= 0 {
+			result.Lsh(a, uint(shift))
+		} else {
+			result.Rsh(a, uint(-shift))
+		}
+	case script.MIN, script.MAX:
+		result.Set(a)
+
+		if (code == script.MIN) == (b.Cmp(a) < 0) {
+			result.Set(b)
+		}
+	case script.NUMEQUAL:
+		engine.pushBool(a.Cmp(b) == 0)
+		return nil
+	case script.NUMNOTEQUAL:
+		engine.pushBool(a.Cmp(b) != 0)
+		return nil
+	case script.LT:
+		engine.pushBool(a.Cmp(b) < 0)
+		return nil
+	case script.GT:
+		engine.pushBool(a.Cmp(b) > 0)
+		return nil
+	case script.LTE:
+		engine.pushBool(a.Cmp(b) <= 0)
+		return nil
+	case script.GTE:
+		engine.pushBool(a.Cmp(b) >= 0)
+		return nil
+	default:
+		return ErrInvalidOpCode
+	}
+
+	return engine.pushInt(result)
+}
+
+func (engine *Engine) executeCrypto(code script.OpCode) error {
+	switch code {
+	case script.SHA1, script.SHA256, script.HASH160, script.HASH256:
+		data, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		var digest []byte
+
+		switch code {
+		case script.SHA1:
+			sum := sha1.Sum(data)
+			digest = sum[:]
+		case script.SHA256:
+			sum := sha256.Sum256(data)
+			digest = sum[:]
+		case script.HASH160:
+			digest = script.Hash(data)
+		case script.HASH256:
+			first := sha256.Sum256(data)
+			sum := sha256.Sum256(first[:])
+			digest = sum[:]
+		}
+
+		return engine.pushBytes(digest)
+	case script.CHECKSIG, script.VERIFY:
+		publicKey, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		signature, err := engine.popBytes()
+
+		if err != nil {
+			return err
+		}
+
+		message := engine.Message
+
+		if code == script.VERIFY {
+			if message, err = engine.popBytes(); err != nil {
+				return err
+			}
+		}
+
+		engine.pushBool(ecc.VerifySignature(publicKey, message, signature))
+
+		return nil
+	case script.CHECKMULTISIG:
+		publicKeys, err := engine.popList()
+
+		if err != nil {
+			return err
+		}
+
+		signatures, err := engine.popList()
+
+		if err != nil {
+			return err
+		}
+
+		if len(publicKeys) == 0 || len(signatures) == 0 || len(signatures) > len(publicKeys) {
+			return ErrInvalidArgument
+		}
+
+		// signatures must be in the same order as the public keys
+		i, j := 0, 0
+
+		for i < len(signatures) && j < len(publicKeys) && len(signatures)-i <= len(publicKeys)-j {
+			if ecc.VerifySignature(publicKeys[j], engine.Message, signatures[i]) {
+				i++
+			}
+
+			j++
+		}
+
+		engine.pushBool(i == len(signatures))
+
+		return nil
+	}
+
+	return ErrInvalidOpCode
+}
+
+// popList pop array of byte arrays or a count followed by that many byte arrays
+func (engine *Engine) popList() ([][]byte, error) {
+	item, err := engine.pop()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var items []StackItem
+
+	if array, ok := asArray(item); ok {
+		items = array
+	} else {
+		value, err := item.BigInt()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !value.IsInt64() || value.Int64() < 1 || value.Int64() > int64(engine.EvaluationStack.Count()) {
+			return nil, fmt.Errorf("%w: count %s", ErrInvalidArgument, value)
+		}
+
+		for i := int64(0); i < value.Int64(); i++ {
+			item, _ := engine.pop()
+
+			items = append(items, item)
+		}
+	}
+
+	var list [][]byte
+
+	for _, item := range items {
+		data, err := item.Bytes()
+
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, data)
+	}
+
+	return list, nil
+}
+
+// asArray get items of array or struct
+func asArray(item StackItem) ([]StackItem, bool) {
+	switch array := item.(type) {
+	case *Array:
+		return array.Items, true
+	case *Struct:
+		return array.Items, true
+	}
+
+	return nil, false
+}
+
+func setArray(item StackItem, items []StackItem) {
+	switch array := item.(type) {
+	case *Array:
+		array.Items = items
+	case *Struct:
+		array.Items = items
+	}
+}
+
+// copyValue copy struct on assignment
+func copyValue(item StackItem) StackItem {
+	if value, ok := item.(*Struct); ok {
+		return value.Clone()
+	}
+
+	return item
+}
+
+func (engine *Engine) executeArray(code script.OpCode) error {
+	switch code {
+	case script.ARRAYSIZE:
+		item, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		size := 0
+
+		if items, ok := asArray(item); ok {
+			size = len(items)
+		} else if value, ok := item.(*Map); ok {
+			size = value.Len()
+		} else {
+			data, err := item.Bytes()
+
+			if err != nil {
+				return err
+			}
+
+			size = len(data)
+		}
+
+		return engine.pushInt(big.NewInt(int64(size)))
+	case script.PACK:
+		n, err := engine.popIndex()
+
+		if err != nil {
+			return err
+		}
+
+		if n < 0 || n > MaxArraySize || n > engine.EvaluationStack.Count() {
+			return fmt.Errorf("%w: pack %d", ErrInvalidArgument, n)
+		}
+
+		items := make([]StackItem, n)
+
+		for i := range items {
+			items[i], _ = engine.pop()
+		}
+
+		engine.push(NewArray(items...))
+
+		return nil
+	case script.UNPACK:
+		item, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		items, ok := asArray(item)
+
+		if !ok {
+			return ErrInvalidType
+		}
+
+		for i := len(items) - 1; i >= 0; i-- {
+			engine.push(items[i])
+		}
+
+		return engine.pushInt(big.NewInt(int64(len(items))))
+	case script.NEWARRAY, script.NEWSTRUCT:
+		item, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		var items []StackItem
+
+		if array, ok := asArray(item); ok {
+			// convert between array and struct
+			items = append(items, array...)
+		} else {
+			value, err := item.BigInt()
+
+			if err != nil {
+				return err
+			}
+
+			if !value.IsInt64() || value.Int64() < 0 || value.Int64() > MaxArraySize {
+				return fmt.Errorf("%w: size %s", ErrInvalidArgument, value)
+			}
+
+			for i := int64(0); i < value.Int64(); i++ {
+				items = append(items, Boolean(false))
+			}
+		}
+
+		if code == script.NEWARRAY {
+			engine.push(NewArray(items...))
+		} else {
+			engine.push(NewStruct(items...))
+		}
+
+		return nil
+	case script.NEWMAP:
+		engine.push(NewMap())
+
+		return nil
+	case script.APPEND:
+		item, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		target, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		items, ok := asArray(target)
+
+		if !ok {
+			return ErrInvalidType
+		}
+
+		if len(items) >= MaxArraySize {
+			return fmt.Errorf("%w: array size", ErrLimit)
+		}
+
+		setArray(target, append(items, copyValue(item)))
+
+		return nil
+	case script.REVERSE:
+		target, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		items, ok := asArray(target)
+
+		if !ok {
+			return ErrInvalidType
+		}
+
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+
+		return nil
+	case script.KEYS, script.VALUES:
+		target, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		var result []StackItem
+
+		if value, ok := target.(*Map); ok {
+			for _, key := range value.Keys() {
+				if code == script.KEYS {
+					result = append(result, key)
+					continue
+				}
+
+				item, _, _ := value.Get(key)
+
+				result = append(result, copyValue(item))
+			}
+		} else if items, ok := asArray(target); ok && code == script.VALUES {
+			for _, item := range items {
+				result = append(result, copyValue(item))
+			}
+		} else {
+			return ErrInvalidType
+		}
+
+		engine.push(NewArray(result...))
+
+		return nil
+	}
+
+	return engine.executeItemAccess(code)
+}
+
+// executeItemAccess PICKITEM, SETITEM, REMOVE and HASKEY
+func (engine *Engine) executeItemAccess(code script.OpCode) error {
+	var value StackItem
+
+	if code == script.SETITEM {
+		item, err := engine.pop()
+
+		if err != nil {
+			return err
+		}
+
+		value = copyValue(item)
+	}
+
+	key, err := engine.pop()
+
+	if err != nil {
+		return err
+	}
+
+	target, err := engine.pop()
+
+	if err != nil {
+		return err
+	}
+
+	if container, ok := target.(*Map); ok {
+		switch code {
+		case script.PICKITEM:
+			item, found, err := container.Get(key)
+
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				return fmt.Errorf("%w: key not found", ErrInvalidArgument)
+			}
+
+			engine.push(item)
+		case script.SETITEM:
+			return container.Set(key, value)
+		case script.REMOVE:
+			return container.Remove(key)
+		case script.HASKEY:
+			_, found, err := container.Get(key)
+
+			if err != nil {
+				return err
+			}
+
+			engine.pushBool(found)
+		default:
+			return ErrInvalidOpCode
+		}
+
+		return nil
+	}
+
+	items, ok := asArray(target)
+
+	if !ok {
+		return ErrInvalidType
+	}
+
+	index, err := key.BigInt()
+
+	if err != nil {
+		return err
+	}
+
+	if !index.IsInt64() || index.Int64() < 0 {
+		return fmt.Errorf("%w: index %s", ErrInvalidArgument, index)
+	}
+
+	i := int(index.Int64())
+
+	if code == script.HASKEY {
+		engine.pushBool(index.Int64() < int64(len(items)))
+
+		return nil
+	}
+
+	if index.Int64() >= int64(len(items)) {
+		return fmt.Errorf("%w: index %s", ErrInvalidArgument, index)
+	}
+
+	switch code {
+	case script.PICKITEM:
+		engine.push(items[i])
+	case script.SETITEM:
+		items[i] = value
+	case script.REMOVE:
+		setArray(target, append(items[:i], items[i+1:]...))
+	default:
+		return ErrInvalidOpCode
+	}
+
+	return nil
+}
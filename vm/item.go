@@ -0,0 +1,454 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Stack item errors
+var (
+	ErrNotPrimitive = errors.New("stack item is not a primitive type")
+	ErrIntegerSize  = errors.New("integer exceeds 32 bytes")
+)
+
+// MaxIntegerSize max size of integer operands in bytes
+const MaxIntegerSize = 32
+
+// ItemType stack item type, the values are the neo 2.x serialization type codes
+type ItemType byte
+
+// Stack item types
+const (
+	ByteArrayType ItemType = 0x00
+	BooleanType   ItemType = 0x01
+	IntegerType   ItemType = 0x02
+	InteropType   ItemType = 0x40
+	ArrayType     ItemType = 0x80
+	StructType    ItemType = 0x81
+	MapType       ItemType = 0x82
+)
+
+var itemTypeNames = map[ItemType]string{
+	ByteArrayType: "ByteArray",
+	BooleanType:   "Boolean",
+	IntegerType:   "Integer",
+	InteropType:   "InteropInterface",
+	ArrayType:     "Array",
+	StructType:    "Struct",
+	MapType:       "Map",
+}
+
+func (itemType ItemType) String() string {
+	if name, ok := itemTypeNames[itemType]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%02x", byte(itemType))
+}
+
+// StackItem NeoVM stack item
+type StackItem interface {
+	Type() ItemType
+	Bytes() ([]byte, error)    // byte array value, ErrNotPrimitive for compound items
+	BigInt() (*big.Int, error) // integer value of the byte array
+	Bool() bool                // boolean value
+	Equals(other StackItem) bool
+}
+
+// ByteArray byte array stack item
+type ByteArray []byte
+
+// Type implement StackItem
+func (item ByteArray) Type() ItemType { return ByteArrayType }
+
+// Bytes implement StackItem
+func (item ByteArray) Bytes() ([]byte, error) { return []byte(item), nil }
+
+// BigInt implement StackItem
+func (item ByteArray) BigInt() (*big.Int, error) {
+	if len(item) > MaxIntegerSize {
+		return nil, ErrIntegerSize
+	}
+
+	return BytesToInt(item), nil
+}
+
+// Bool any non zero byte is true
+func (item ByteArray) Bool() bool {
+	for _, b := range item {
+		if b != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Equals implement StackItem
+func (item ByteArray) Equals(other StackItem) bool {
+	return primitiveEquals(item, other)
+}
+
+func (item ByteArray) String() string {
+	return hex.EncodeToString(item)
+}
+
+// Integer integer stack item
+type Integer struct {
+	value *big.Int
+}
+
+// NewInteger create integer stack item
+func NewInteger(value *big.Int) *Integer {
+	return &Integer{value: new(big.Int).Set(value)}
+}
+
+// Type implement StackItem
+func (item *Integer) Type() ItemType { return IntegerType }
+
+// Bytes little endian two's complement
+func (item *Integer) Bytes() ([]byte, error) { return IntToBytes(item.value), nil }
+
+// BigInt implement StackItem
+func (item *Integer) BigInt() (*big.Int, error) { return new(big.Int).Set(item.value), nil }
+
+// Bool non zero is true
+func (item *Integer) Bool() bool { return item.value.Sign() != 0 }
+
+// Equals implement StackItem
+func (item *Integer) Equals(other StackItem) bool {
+	if integer, ok := other.(*Integer); ok {
+		return item.value.Cmp(integer.value) == 0
+	}
+
+	return primitiveEquals(item, other)
+}
+
+func (item *Integer) String() string {
+	return item.value.String()
+}
+
+// Boolean boolean stack item
+type Boolean bool
+
+// Type implement StackItem
+func (item Boolean) Type() ItemType { return BooleanType }
+
+// Bytes true is 0x01, false is empty
+func (item Boolean) Bytes() ([]byte, error) {
+	if item {
+		return []byte{1}, nil
+	}
+
+	return []byte{}, nil
+}
+
+// BigInt implement StackItem
+func (item Boolean) BigInt() (*big.Int, error) {
+	if item {
+		return big.NewInt(1), nil
+	}
+
+	return big.NewInt(0), nil
+}
+
+// Bool implement StackItem
+func (item Boolean) Bool() bool { return bool(item) }
+
+// Equals implement StackItem
+func (item Boolean) Equals(other StackItem) bool {
+	return primitiveEquals(item, other)
+}
+
+// Array array stack item, a reference type
+type Array struct {
+	Items []StackItem
+}
+
+// NewArray create array stack item
+func NewArray(items ...StackItem) *Array {
+	return &Array{Items: items}
+}
+
+// Type implement StackItem
+func (item *Array) Type() ItemType { return ArrayType }
+
+// Bytes implement StackItem
+func (item *Array) Bytes() ([]byte, error) { return nil, ErrNotPrimitive }
+
+// BigInt implement StackItem
+func (item *Array) BigInt() (*big.Int, error) { return nil, ErrNotPrimitive }
+
+// Bool implement StackItem
+func (item *Array) Bool() bool { return true }
+
+// Equals reference equality
+func (item *Array) Equals(other StackItem) bool {
+	return StackItem(item) == other
+}
+
+// Struct struct stack item, a value type which is copied on assignment
+type Struct struct {
+	Items []StackItem
+}
+
+// NewStruct create struct stack item
+func NewStruct(items ...StackItem) *Struct {
+	return &Struct{Items: items}
+}
+
+// Type implement StackItem
+func (item *Struct) Type() ItemType { return StructType }
+
+// Bytes implement StackItem
+func (item *Struct) Bytes() ([]byte, error) { return nil, ErrNotPrimitive }
+
+// BigInt implement StackItem
+func (item *Struct) BigInt() (*big.Int, error) { return nil, ErrNotPrimitive }
+
+// Bool implement StackItem
+func (item *Struct) Bool() bool { return true }
+
+// Equals deep equality of struct fields
+func (item *Struct) Equals(other StackItem) bool {
+	target, ok := other.(*Struct)
+
+	if !ok {
+		return false
+	}
+
+	if item == target {
+		return true
+	}
+
+	if len(item.Items) != len(target.Items) {
+		return false
+	}
+
+	for i := range item.Items {
+		if !item.Items[i].Equals(target.Items[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Clone deep copy nested structs, other items are shared
+func (item *Struct) Clone() *Struct {
+	clone := &Struct{Items: make([]StackItem, len(item.Items))}
+
+	for i, field := range item.Items {
+		if nested, ok := field.(*Struct); ok {
+			field = nested.Clone()
+		}
+
+		clone.Items[i] = field
+	}
+
+	return clone
+}
+
+// Map map stack item with primitive keys, keys keep insertion order
+type Map struct {
+	keys   []StackItem
+	values map[string]StackItem
+}
+
+// NewMap create map stack item
+func NewMap() *Map {
+	return &Map{values: make(map[string]StackItem)}
+}
+
+// Type implement StackItem
+func (item *Map) Type() ItemType { return MapType }
+
+// Bytes implement StackItem
+func (item *Map) Bytes() ([]byte, error) { return nil, ErrNotPrimitive }
+
+// BigInt implement StackItem
+func (item *Map) BigInt() (*big.Int, error) { return nil, ErrNotPrimitive }
+
+// Bool implement StackItem
+func (item *Map) Bool() bool { return true }
+
+// Equals reference equality
+func (item *Map) Equals(other StackItem) bool {
+	return StackItem(item) == other
+}
+
+// Len get entry count
+func (item *Map) Len() int {
+	return len(item.keys)
+}
+
+// Keys get keys in insertion order
+func (item *Map) Keys() []StackItem {
+	return append([]StackItem{}, item.keys...)
+}
+
+// Get get value of key
+func (item *Map) Get(key StackItem) (StackItem, bool, error) {
+	id, err := mapKey(key)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := item.values[id]
+
+	return value, ok, nil
+}
+
+// Set set value of key
+func (item *Map) Set(key StackItem, value StackItem) error {
+	id, err := mapKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := item.values[id]; !ok {
+		item.keys = append(item.keys, key)
+	}
+
+	item.values[id] = value
+
+	return nil
+}
+
+// Remove remove key
+func (item *Map) Remove(key StackItem) error {
+	id, err := mapKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := item.values[id]; !ok {
+		return nil
+	}
+
+	delete(item.values, id)
+
+	for i, target := range item.keys {
+		if target.Equals(key) {
+			item.keys = append(item.keys[:i], item.keys[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func mapKey(key StackItem) (string, error) {
+	data, err := key.Bytes()
+
+	if err != nil {
+		return "", fmt.Errorf("invalid map key %s: %s", key.Type(), err)
+	}
+
+	return string(data), nil
+}
+
+// InteropInterface opaque host object pushed by interop services
+type InteropInterface struct {
+	Value interface{}
+}
+
+// NewInteropInterface create interop stack item
+func NewInteropInterface(value interface{}) *InteropInterface {
+	return &InteropInterface{Value: value}
+}
+
+// Type implement StackItem
+func (item *InteropInterface) Type() ItemType { return InteropType }
+
+// Bytes implement StackItem
+func (item *InteropInterface) Bytes() ([]byte, error) { return nil, ErrNotPrimitive }
+
+// BigInt implement StackItem
+func (item *InteropInterface) BigInt() (*big.Int, error) { return nil, ErrNotPrimitive }
+
+// Bool implement StackItem
+func (item *InteropInterface) Bool() bool { return item.Value != nil }
+
+// Equals same host object
+func (item *InteropInterface) Equals(other StackItem) bool {
+	target, ok := other.(*InteropInterface)
+
+	return ok && item.Value == target.Value
+}
+
+// primitiveEquals compare byte arrays of primitive items
+func primitiveEquals(item StackItem, other StackItem) bool {
+	data, err := item.Bytes()
+
+	if err != nil {
+		return false
+	}
+
+	otherData, err := other.Bytes()
+
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(data, otherData)
+}
+
+// BytesToInt decode little endian two's complement integer, empty is zero
+func BytesToInt(data []byte) *big.Int {
+	if len(data) == 0 {
+		return big.NewInt(0)
+	}
+
+	bigEndian := make([]byte, len(data))
+
+	for i, b := range data {
+		bigEndian[len(data)-1-i] = b
+	}
+
+	value := new(big.Int).SetBytes(bigEndian)
+
+	if bigEndian[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+	}
+
+	return value
+}
+
+// IntToBytes encode integer as minimal little endian two's complement, zero is empty
+func IntToBytes(value *big.Int) []byte {
+	if value.Sign() == 0 {
+		return []byte{}
+	}
+
+	magnitude := value
+
+	if value.Sign() < 0 {
+		// -n = ^(n-1)
+		magnitude = new(big.Int).Not(value)
+	}
+
+	bigEndian := magnitude.Bytes()
+
+	if len(bigEndian) == 0 || bigEndian[0]&0x80 != 0 {
+		bigEndian = append([]byte{0}, bigEndian...)
+	}
+
+	data := make([]byte, len(bigEndian))
+
+	for i, b := range bigEndian {
+		if value.Sign() < 0 {
+			b = ^b
+		}
+
+		data[len(bigEndian)-1-i] = b
+	}
+
+	return data
+}
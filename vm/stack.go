@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"errors"
+)
+
+// Stack errors
+var (
+	ErrStackUnderflow = errors.New("stack underflow")
+	ErrStackIndex     = errors.New("stack index out of range")
+)
+
+// Stack random access stack, index 0 is the top item
+type Stack struct {
+	items []StackItem
+}
+
+// NewStack create empty stack
+func NewStack() *Stack {
+	return &Stack{}
+}
+
+// Count get item count
+func (stack *Stack) Count() int {
+	return len(stack.items)
+}
+
+// Push push item on top
+func (stack *Stack) Push(item StackItem) {
+	stack.items = append(stack.items, item)
+}
+
+// Peek get the item n back from the top
+func (stack *Stack) Peek(n int) (StackItem, error) {
+	if n < 0 || n >= len(stack.items) {
+		return nil, ErrStackIndex
+	}
+
+	return stack.items[len(stack.items)-1-n], nil
+}
+
+// Pop remove and return the top item
+func (stack *Stack) Pop() (StackItem, error) {
+	if len(stack.items) == 0 {
+		return nil, ErrStackUnderflow
+	}
+
+	return stack.Remove(0)
+}
+
+// moveTo move the top n items to target keeping their order
+func (stack *Stack) moveTo(target *Stack, n int) error {
+	if n < 0 || n > len(stack.items) {
+		return ErrStackUnderflow
+	}
+
+	index := len(stack.items) - n
+
+	target.items = append(target.items, stack.items[index:]...)
+	stack.items = stack.items[:index]
+
+	return nil
+}
+
+// Insert insert item n back from the top, 0 is the same as Push
+func (stack *Stack) Insert(n int, item StackItem) error {
+	if n < 0 || n > len(stack.items) {
+		return ErrStackIndex
+	}
+
+	index := len(stack.items) - n
+
+	stack.items = append(stack.items, nil)
+
+	copy(stack.items[index+1:], stack.items[index:])
+
+	stack.items[index] = item
+
+	return nil
+}
+
+// Remove remove and return the item n back from the top
+func (stack *Stack) Remove(n int) (StackItem, error) {
+	if n < 0 || n >= len(stack.items) {
+		return nil, ErrStackIndex
+	}
+
+	index := len(stack.items) - 1 - n
+
+	item := stack.items[index]
+
+	stack.items = append(stack.items[:index], stack.items[index+1:]...)
+
+	return item, nil
+}
+
+// Set replace the item n back from the top
+func (stack *Stack) Set(n int, item StackItem) error {
+	if n < 0 || n >= len(stack.items) {
+		return ErrStackIndex
+	}
+
+	stack.items[len(stack.items)-1-n] = item
+
+	return nil
+}
+
+// Items get items from top to bottom
+func (stack *Stack) Items() []StackItem {
+	items := make([]StackItem, len(stack.items))
+
+	for i, item := range stack.items {
+		items[len(stack.items)-1-i] = item
+	}
+
+	return items
+}
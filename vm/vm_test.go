@@ -0,0 +1,440 @@
+package vm
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/inwecrypto/neogo/keystore"
+	"github.com/inwecrypto/neogo/script"
+	"github.com/inwecrypto/neogo/tx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assemble(t *testing.T, source string) []byte {
+	asm, err := script.Assemble(source)
+	require.NoError(t, err)
+
+	code, err := asm.Bytes()
+	require.NoError(t, err)
+
+	return code
+}
+
+func resultInt(t *testing.T, engine *Engine) int64 {
+	item, err := engine.EvaluationStack.Peek(0)
+	require.NoError(t, err)
+
+	value, err := item.BigInt()
+	require.NoError(t, err)
+
+	return value.Int64()
+}
+
+func TestIntegerEncoding(t *testing.T) {
+	for value, encoded := range map[int64]string{
+		0:    "",
+		1:    "01",
+		-1:   "ff",
+		127:  "7f",
+		128:  "8000",
+		-128: "80",
+		-129: "7fff",
+		255:  "ff00",
+		1000: "e803",
+	} {
+		data := IntToBytes(big.NewInt(value))
+		assert.Equal(t, encoded, hex.EncodeToString(data), "%d", value)
+		assert.Equal(t, value, BytesToInt(data).Int64())
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	engine := Run(assemble(t, `
+		PUSH 2
+		PUSH 3
+		ADD
+		PUSH -7
+		MUL
+		PUSH 4
+		DIV        ; truncated: -35 / 4 = -8
+		PUSH -8
+		NUMEQUAL
+		PUSH 10
+		PUSH 3
+		MOD
+		PUSH 1
+		PUSH 4
+		SHL
+		PUSH 5
+		PUSH 0
+		PUSH 10
+		WITHIN
+	`))
+
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+
+	items := engine.EvaluationStack.Items()
+	require.Len(t, items, 4)
+	assert.True(t, items[0].Bool())
+	assert.Equal(t, "16", fmt.Sprint(items[1]))
+	assert.Equal(t, "1", fmt.Sprint(items[2]))
+	assert.True(t, items[3].Bool())
+
+	// pushes are free, the 7 operations and the implicit RET cost one unit each
+	assert.Equal(t, int64(8*GasRatio), engine.GasConsumed)
+
+	// negative shifts shift the other way, right shifts round down
+	for source, expect := range map[string]string{
+		"PUSH 1\nPUSH -4\nSHR":   "16",
+		"PUSH 16\nPUSH -4\nSHL":  "1",
+		"PUSH -5\nPUSH 1\nSHR":   "-3",
+		"PUSH -5\nPUSH -1\nSHL":  "-3",
+		"PUSH 1\nPUSH -256\nSHL": "0",
+	} {
+		engine = Run(assemble(t, source))
+
+		require.Equal(t, Halt, engine.State, "%s: %v", source, engine.Err)
+		assert.Equal(t, expect, fmt.Sprint(engine.EvaluationStack.Items()[0]), source)
+	}
+}
+
+func TestControlFlow(t *testing.T) {
+	// sum 1..10 with a loop and a subroutine
+	engine := Run(assemble(t, `
+		PUSH 0
+		PUSH 10
+	loop:
+		DUP
+		JMPIFNOT done
+		TUCK
+		CALL add
+		SWAP
+		DEC
+		JMP loop
+	done:
+		DROP
+		RET
+	add:
+		ADD
+		RET
+	`))
+
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+	assert.Equal(t, int64(55), resultInt(t, engine))
+	assert.Equal(t, 1, engine.EvaluationStack.Count())
+}
+
+func TestCollections(t *testing.T) {
+	engine := Run(assemble(t, `
+		NEWMAP
+		DUP
+		PUSH "name"
+		PUSH "neo"
+		SETITEM
+		DUP
+		PUSH 1
+		PUSH 100
+		SETITEM
+		DUP
+		PUSH 1
+		PICKITEM
+		TOALTSTACK
+		DUP
+		PUSH "name"
+		HASKEY
+		TOALTSTACK
+		KEYS
+		ARRAYSIZE
+		FROMALTSTACK
+		FROMALTSTACK
+		PUSH 3
+		PACK
+		DUP
+		REVERSE
+		DUP
+		PUSH 2
+		NEWSTRUCT
+		DUP
+		TOALTSTACK
+		APPEND
+		DUPFROMALTSTACK
+		PUSH 0
+		PUSH 7
+		SETITEM
+		FROMALTSTACK
+		ARRAYSIZE
+	`))
+
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+
+	items := engine.EvaluationStack.Items()
+	require.Len(t, items, 2)
+	assert.Equal(t, "2", fmt.Sprint(items[0]))
+
+	array, ok := items[1].(*Array)
+	require.True(t, ok)
+	require.Len(t, array.Items, 4)
+
+	// packed [100, true, 2] reversed, then the appended struct copy
+	assert.Equal(t, "2", fmt.Sprint(array.Items[0]))
+	assert.True(t, array.Items[1].Bool())
+	value, err := array.Items[2].BigInt()
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), value.Int64())
+
+	// struct is copied on APPEND, the later SETITEM does not change the copy
+	appended, ok := array.Items[3].(*Struct)
+	require.True(t, ok)
+	assert.True(t, appended.Equals(NewStruct(Boolean(false), Boolean(false))))
+}
+
+func TestCheckSig(t *testing.T) {
+	key, err := keystore.NewKey()
+	require.NoError(t, err)
+
+	signer := tx.NewKeySigner(key.PrivateKey)
+
+	message := []byte("message")
+
+	signature, err := signer.Sign(message)
+	require.NoError(t, err)
+
+	invocation, err := script.New("invocation").EmitPushBytes(signature).Bytes()
+	require.NoError(t, err)
+
+	verification := tx.CreateSignatureRedeemScript(signer.PublicKey())
+
+	run := func(message []byte) *Engine {
+		engine := NewEngine(WithMessage(message))
+
+		require.NoError(t, engine.LoadScript(verification))
+		require.NoError(t, engine.LoadScript(invocation))
+
+		engine.Execute()
+
+		return engine
+	}
+
+	engine := run(message)
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+	assert.Equal(t, 1, engine.EvaluationStack.Count())
+	assert.True(t, engine.EvaluationStack.Items()[0].Bool())
+	// CHECKSIG and the implicit RET of both scripts
+	assert.Equal(t, int64(102*GasRatio), engine.GasConsumed)
+
+	engine = run([]byte("other"))
+	require.Equal(t, Halt, engine.State)
+	assert.False(t, engine.EvaluationStack.Items()[0].Bool())
+
+	// 2 of 3 multi-signature
+	var signers []tx.Signer
+	var publicKeys [][]byte
+
+	for i := 0; i < 3; i++ {
+		key, err := keystore.NewKey()
+		require.NoError(t, err)
+
+		signer := tx.NewKeySigner(key.PrivateKey)
+
+		signers = append(signers, signer)
+		publicKeys = append(publicKeys, signer.PublicKey())
+	}
+
+	witness, err := tx.NewMultiSigWitness(2, publicKeys)
+	require.NoError(t, err)
+
+	for _, signer := range signers[1:] {
+		signature, err := signer.Sign(message)
+		require.NoError(t, err)
+		require.NoError(t, witness.AddSignature(signer.PublicKey(), signature))
+	}
+
+	scripts, err := witness.Scripts()
+	require.NoError(t, err)
+
+	engine = NewEngine(WithMessage(message))
+
+	require.NoError(t, engine.LoadScript(scripts.RedeemScript))
+	require.NoError(t, engine.LoadScript(scripts.StackScript))
+
+	require.Equal(t, Halt, engine.Execute(), "%v", engine.Err)
+	assert.True(t, engine.EvaluationStack.Items()[0].Bool())
+	assert.Equal(t, int64((2+300)*GasRatio), engine.GasConsumed)
+}
+
+type scriptTable map[string][]byte
+
+func (table scriptTable) GetScript(scriptHash []byte) ([]byte, error) {
+	if code, ok := table[hex.EncodeToString(scriptHash)]; ok {
+		return code, nil
+	}
+
+	return nil, fmt.Errorf("unknown contract %x", scriptHash)
+}
+
+func TestAppCallAndSysCall(t *testing.T) {
+	contract := assemble(t, `
+		PUSH 2
+		MUL
+		SYSCALL Test.Double
+		RET
+	`)
+
+	scriptHash := script.Hash(contract)
+
+	bigEndian := make([]byte, 20)
+
+	for i, b := range scriptHash {
+		bigEndian[19-i] = b
+	}
+
+	var apis []string
+
+	engine := Run(assemble(t, fmt.Sprintf(`
+		PUSH 21
+		APPCALL 0x%x
+		PUSH 1
+		ADD
+	`, bigEndian)), WithScriptTable(scriptTable{hex.EncodeToString(scriptHash): contract}), WithSysCall(func(engine *Engine, api string) error {
+		apis = append(apis, api)
+
+		return engine.AddGas(5)
+	}))
+
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+	assert.Equal(t, int64(43), resultInt(t, engine))
+	assert.Equal(t, []string{"Test.Double"}, apis)
+
+	// APPCALL 10 + MUL + SYSCALL 1+5 + RET + ADD + implicit RET
+	assert.Equal(t, int64(20*GasRatio), engine.GasConsumed)
+}
+
+func TestFault(t *testing.T) {
+	for source, expect := range map[string]error{
+		"THROW":                   ErrThrow,
+		"PUSH 0\nTHROWIFNOT":      ErrThrow,
+		"PUSH 1\nPUSH 0\nDIV":     ErrDivideByZero,
+		"ADD":                     ErrStackUnderflow,
+		"SYSCALL Neo.Runtime.Log": ErrNoSysCallHandler,
+		"APPCALL 0x" + hex.EncodeToString(make([]byte, 19)) + "01": ErrNoScriptTable,
+		"NEWMAP\nPUSH 1\nPICKITEM":                                 ErrInvalidArgument,
+		"PUSH 1\nPUSH 1\nPICKITEM":                                 ErrInvalidType,
+		"PUSH 1\nPUSH 257\nSHL":                                    ErrInvalidArgument,
+		"PUSH 1\nPUSH -257\nSHR":                                   ErrInvalidArgument,
+		"JMP -10":                                                  ErrInvalidJump,
+	} {
+		engine := Run(assemble(t, source))
+
+		assert.Equal(t, Fault, engine.State, source)
+		assert.True(t, errors.Is(engine.Err, expect), "%s: %v", source, engine.Err)
+	}
+
+	engine := Run([]byte{0xAB})
+	assert.Equal(t, Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrInvalidOpCode))
+
+	// integer results are limited to 32 bytes
+	engine = Run(assemble(t, "PUSH 1\nPUSH 255\nSHL\nPUSH 2\nMUL"))
+	assert.Equal(t, Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrIntegerSize))
+
+	// infinite loop stops at the gas limit
+	engine = Run(assemble(t, "loop:\nNOP\nPUSH 1\nDROP\nJMP loop"), WithGasLimit(10*GasRatio))
+	assert.Equal(t, Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrGasLimit))
+	assert.Equal(t, int64(11*GasRatio), engine.GasConsumed)
+}
+
+func TestCallOps(t *testing.T) {
+	engine := Run(assemble(t, `
+		PUSH 2
+		PUSH 3
+		CALL_I 1 2 add
+		PUSH 10
+		MUL
+		RET
+	add:
+		ADD
+		RET
+	`))
+
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+	assert.Equal(t, int64(50), resultInt(t, engine))
+	// CALL_I, ADD, RET, MUL, RET
+	assert.Equal(t, int64(5*GasRatio), engine.GasConsumed)
+
+	// the subroutine must return the declared count of values
+	engine = Run(assemble(t, "PUSH 2\nPUSH 3\nCALL_I 2 2 add\nRET\nadd:\nADD\nRET"))
+	assert.Equal(t, Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrInvalidArgument), "%v", engine.Err)
+
+	contract := assemble(t, "PUSH 2\nMUL")
+
+	scriptHash := script.Hash(contract)
+
+	bigEndian := make([]byte, 20)
+
+	for i, b := range scriptHash {
+		bigEndian[19-i] = b
+	}
+
+	table := WithScriptTable(scriptTable{hex.EncodeToString(scriptHash): contract})
+
+	for _, source := range []string{
+		fmt.Sprintf("PUSH 21\nCALL_E 1 1 0x%x", bigEndian),
+		fmt.Sprintf("PUSH 21\nPUSH 0x%x\nCALL_ED 1 1", scriptHash),
+	} {
+		engine = Run(assemble(t, source), table)
+
+		require.Equal(t, Halt, engine.State, "%s: %v", source, engine.Err)
+		assert.Equal(t, int64(42), resultInt(t, engine), source)
+	}
+
+	// entry context has no return count to match
+	engine = Run(assemble(t, fmt.Sprintf("PUSH 21\nCALL_ET 1 1 0x%x", bigEndian)), table)
+	assert.Equal(t, Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrInvalidArgument), "%v", engine.Err)
+
+	// the called context can't reach the caller items below its parameters
+	engine = Run(assemble(t, "PUSH 7\nCALL_I 1 0 f\nRET\nf:\nDUP\nRET"))
+	assert.Equal(t, Fault, engine.State)
+
+	engine = Run(assemble(t, "PUSH 7\nPUSH 8\nCALL_I 1 1 f\nADD\nRET\nf:\nDEPTH\nADD\nRET"))
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+	assert.Equal(t, int64(16), resultInt(t, engine))
+
+	// TAILCALL keeps the return count of the replaced context
+	tables := scriptTable{hex.EncodeToString(scriptHash): contract}
+
+	deploy := func(source string) string {
+		code := assemble(t, source)
+
+		hash := script.Hash(code)
+
+		tables[hex.EncodeToString(hash)] = code
+
+		reversed := make([]byte, 20)
+
+		for i, b := range hash {
+			reversed[19-i] = b
+		}
+
+		return fmt.Sprintf("0x%x", reversed)
+	}
+
+	tailCaller := deploy(fmt.Sprintf("TAILCALL 0x%x", bigEndian))
+
+	engine = Run(assemble(t, fmt.Sprintf("PUSH 5\nPUSH 21\nCALL_E 1 1 %s\nADD", tailCaller)), WithScriptTable(tables))
+	require.Equal(t, Halt, engine.State, "%v", engine.Err)
+	assert.Equal(t, int64(47), resultInt(t, engine))
+
+	tailCaller = deploy("TAILCALL " + deploy("PUSH 2\nMUL\nPUSH 0"))
+
+	engine = Run(assemble(t, fmt.Sprintf("PUSH 5\nPUSH 21\nCALL_E 1 1 %s\nADD", tailCaller)), WithScriptTable(tables))
+	assert.Equal(t, Fault, engine.State)
+	assert.True(t, errors.Is(engine.Err, ErrInvalidArgument), "%v", engine.Err)
+}